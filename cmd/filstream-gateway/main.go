@@ -0,0 +1,81 @@
+// Command filstream-gateway serves FilStream content from Curio nodes over
+// HTTP at /ipfs/{cid} and /content/{cid}.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/quriustus/filstream-curio-adapter/pkg/curio"
+	"github.com/quriustus/filstream-curio-adapter/pkg/gateway"
+	"github.com/quriustus/filstream-curio-adapter/pkg/moderation"
+	"github.com/quriustus/filstream-curio-adapter/pkg/policy"
+	"github.com/quriustus/filstream-curio-adapter/pkg/routing"
+)
+
+func main() {
+	listen := flag.String("listen", ":8080", "HTTP listen address")
+	nodes := flag.String("curio", "", "comma-separated Curio nodes as id=url (or bare urls)")
+	geo := flag.String("geo", "", "preferred geo label for node ranking")
+	denyFile := flag.String("denylist", "", "file of denied CIDs, one per line")
+	policyFile := flag.String("policy", "", "policy config file (.json or key: value lines), reloaded on change")
+	flag.Parse()
+
+	if *nodes == "" {
+		log.Fatal("filstream-gateway: -curio is required")
+	}
+
+	cfg := policy.DefaultConfig()
+	if *policyFile != "" {
+		var err error
+		if cfg, err = policy.LoadConfig(*policyFile); err != nil {
+			log.Fatalf("filstream-gateway: %v", err)
+		}
+	}
+	engine := policy.NewEngine(cfg)
+	go engine.RunEvictor(context.Background(), time.Hour, func(ids []string) {
+		log.Printf("filstream-gateway: evicted idle nodes %v", ids)
+	})
+	if *policyFile != "" {
+		go engine.RunConfigReloader(context.Background(), *policyFile, 10*time.Second, func(err error) {
+			log.Printf("filstream-gateway: policy reload: %v", err)
+		})
+	}
+	routed := routing.NewRoutedRetriever(engine, routing.Config{
+		PreferredGeo:   *geo,
+		AttemptTimeout: 5 * time.Second,
+	})
+	for i, spec := range strings.Split(*nodes, ",") {
+		id, url := fmt.Sprintf("node-%d", i), strings.TrimSpace(spec)
+		if k, v, ok := strings.Cut(url, "="); ok {
+			id, url = k, v
+		}
+		client, err := curio.NewClient(url, nil)
+		if err != nil {
+			log.Fatalf("filstream-gateway: node %s: %v", id, err)
+		}
+		routed.AddNode(id, client)
+	}
+
+	deny := moderation.NewMemoryDenyList()
+	if *denyFile != "" {
+		n, err := moderation.LoadDenyListFile(deny, *denyFile, "denylist file")
+		if err != nil {
+			log.Fatalf("filstream-gateway: %v", err)
+		}
+		log.Printf("filstream-gateway: loaded %d denied CIDs from %s", n, *denyFile)
+	}
+
+	srv := &http.Server{
+		Addr:              *listen,
+		Handler:           gateway.NewServer(routed, deny, gateway.DefaultConfig()),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	log.Printf("filstream-gateway listening on %s", *listen)
+	log.Fatal(srv.ListenAndServe())
+}
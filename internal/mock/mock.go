@@ -6,6 +6,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"sort"
 	"sync"
 	"time"
 
@@ -72,7 +73,7 @@ func (b *Backend) Get(ctx context.Context, cid string) (io.ReadCloser, error) {
 
 	data, ok := b.objects[cid]
 	if !ok {
-		return nil, fmt.Errorf("cid not found: %s", cid)
+		return nil, fmt.Errorf("%w: %s", adapter.ErrNotFound, cid)
 	}
 	return io.NopCloser(bytes.NewReader(data)), nil
 }
@@ -83,14 +84,33 @@ func (b *Backend) GetRange(ctx context.Context, cid string, start, end uint64) (
 
 	data, ok := b.objects[cid]
 	if !ok {
-		return nil, fmt.Errorf("cid not found: %s", cid)
+		return nil, fmt.Errorf("%w: %s", adapter.ErrNotFound, cid)
 	}
 	if start >= uint64(len(data)) || end > uint64(len(data)) || start >= end {
-		return nil, fmt.Errorf("invalid range [%d, %d) for object of size %d", start, end, len(data))
+		return nil, fmt.Errorf("%w: [%d, %d) for object of size %d", adapter.ErrRangeNotSatisfiable, start, end, len(data))
 	}
 	return io.NopCloser(bytes.NewReader(data[start:end])), nil
 }
 
+// --- Stater ---
+
+// Stat reports the object's size. Every mock node is listed as holding it.
+func (b *Backend) Stat(ctx context.Context, cid string) (adapter.ObjectInfo, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	data, ok := b.objects[cid]
+	if !ok {
+		return adapter.ObjectInfo{}, fmt.Errorf("%w: %s", adapter.ErrNotFound, cid)
+	}
+	nodes := make([]string, 0, len(b.nodes))
+	for id := range b.nodes {
+		nodes = append(nodes, id)
+	}
+	sort.Strings(nodes)
+	return adapter.ObjectInfo{CID: cid, Size: uint64(len(data)), Codec: "raw", Nodes: nodes}, nil
+}
+
 // --- HealthChecker ---
 
 func (b *Backend) CheckHealth(ctx context.Context, nodeID string) (adapter.HealthStatus, error) {
@@ -125,6 +145,7 @@ func (b *Backend) ProofTTL() time.Duration {
 // Compile-time interface checks.
 var (
 	_ adapter.RetrieverAPI  = (*Backend)(nil)
+	_ adapter.Stater        = (*Backend)(nil)
 	_ adapter.HealthChecker = (*Backend)(nil)
 	_ adapter.ProofVerifier = (*Backend)(nil)
 )
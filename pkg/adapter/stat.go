@@ -0,0 +1,62 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// OpenEnd requests a range that runs to the end of the object. Pass it as
+// the end of ClampRange to read [start, size).
+const OpenEnd uint64 = math.MaxUint64
+
+// Sentinel errors shared by RetrieverAPI and Stater implementations.
+var (
+	ErrNotFound            = errors.New("content not found")
+	ErrRangeNotSatisfiable = errors.New("range not satisfiable")
+	ErrStatUnsupported     = errors.New("retriever does not support Stat")
+)
+
+// ObjectInfo describes a stored object.
+type ObjectInfo struct {
+	CID   string
+	Size  uint64
+	Codec string   // e.g. "raw", "dag-pb", "fil-commitment-unsealed"
+	Nodes []string // storage nodes known to hold the object, if known
+}
+
+// Stater is optionally implemented by a RetrieverAPI that can report object
+// metadata without transferring the content. Callers use it to learn the
+// size N before building a [0, N) range.
+type Stater interface {
+	// Stat returns metadata for the given CID.
+	Stat(ctx context.Context, cid string) (ObjectInfo, error)
+}
+
+// StatOf calls Stat if r implements Stater and returns ErrStatUnsupported
+// otherwise. Decorators use it to pass Stat through to the wrapped retriever.
+func StatOf(ctx context.Context, r RetrieverAPI, cid string) (ObjectInfo, error) {
+	s, ok := r.(Stater)
+	if !ok {
+		return ObjectInfo{}, ErrStatUnsupported
+	}
+	return s.Stat(ctx, cid)
+}
+
+// ClampRange resolves [start, end) against the object's size: an end past
+// the object (including OpenEnd) is clamped to the size. It returns
+// ErrRangeNotSatisfiable if start is at or beyond the end of the object.
+func ClampRange(ctx context.Context, s Stater, cid string, start, end uint64) (uint64, uint64, error) {
+	info, err := s.Stat(ctx, cid)
+	if err != nil {
+		return 0, 0, err
+	}
+	if end > info.Size {
+		end = info.Size
+	}
+	if start >= end {
+		return 0, 0, fmt.Errorf("%w: [%d, %d) for object of size %d", ErrRangeNotSatisfiable, start, end, info.Size)
+	}
+	return start, end, nil
+}
@@ -0,0 +1,403 @@
+// Package cache provides a caching decorator for adapter.RetrieverAPI so
+// popular FilStream segments are not fetched from Curio on every request.
+package cache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/quriustus/filstream-curio-adapter/pkg/adapter"
+	"github.com/quriustus/filstream-curio-adapter/pkg/moderation"
+)
+
+// errUncacheable marks a shared miss whose body exceeded MaxEntryBytes; only
+// the leading caller received the stream, so followers fetch on their own.
+var errUncacheable = errors.New("cache: object too large to cache")
+
+// Config holds cache budgets.
+type Config struct {
+	// MaxMemoryBytes is the byte budget of the in-memory LRU tier.
+	MaxMemoryBytes int64
+
+	// MaxEntryBytes is the largest single object or range that is cached.
+	// Larger responses are streamed through uncached.
+	MaxEntryBytes int64
+
+	// DiskDir enables the on-disk tier. Entries evicted from memory are
+	// demoted here. Empty disables the disk tier.
+	DiskDir string
+
+	// MaxDiskBytes is the byte budget of the on-disk tier.
+	MaxDiskBytes int64
+}
+
+// DefaultConfig returns a Config with sensible defaults: 256MB in memory,
+// 16MB per entry, no disk tier.
+func DefaultConfig() Config {
+	return Config{
+		MaxMemoryBytes: 256 << 20,
+		MaxEntryBytes:  16 << 20,
+	}
+}
+
+// Stats are cumulative cache counters.
+type Stats struct {
+	Hits         uint64 // served from memory
+	DiskHits     uint64 // served from disk (and promoted to memory)
+	Misses       uint64
+	Evictions    uint64 // entries dropped from the last tier
+	DeniedPurges uint64 // CIDs purged because they were denied
+	MemoryBytes  int64
+	DiskBytes    int64
+}
+
+// Retriever caches responses from another RetrieverAPI. Range reads are
+// served from any cached range (or full object) that covers them, and
+// concurrent misses for the same CID/range share a single upstream fetch.
+// If a DenyList is configured, every request consults it and denied content
+// is purged from both tiers.
+type Retriever struct {
+	next   adapter.RetrieverAPI
+	deny   moderation.DenyList
+	config Config
+
+	mu      sync.Mutex
+	mem     *tier
+	disk    *tier
+	flights map[entryKey]*flight
+	stats   Stats
+}
+
+type flight struct {
+	done chan struct{}
+	data []byte
+	err  error
+}
+
+// NewRetriever wraps next with a cache. deny may be nil.
+func NewRetriever(next adapter.RetrieverAPI, deny moderation.DenyList, cfg Config) (*Retriever, error) {
+	r := &Retriever{
+		next:    next,
+		deny:    deny,
+		config:  cfg,
+		mem:     newTier(cfg.MaxMemoryBytes),
+		flights: make(map[entryKey]*flight),
+	}
+	if cfg.DiskDir != "" {
+		if err := os.MkdirAll(cfg.DiskDir, 0o755); err != nil {
+			return nil, fmt.Errorf("cache: create disk dir: %w", err)
+		}
+		r.disk = newTier(cfg.MaxDiskBytes)
+	}
+	return r, nil
+}
+
+// Get retrieves the full content for cid, from cache when possible.
+func (r *Retriever) Get(ctx context.Context, cid string) (io.ReadCloser, error) {
+	if err := r.checkDenied(cid); err != nil {
+		return nil, err
+	}
+	if data, ok := r.lookup(cid, 0, 0, true); ok {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	return r.fetch(ctx, entryKey{cid: cid, full: true}, func(ctx context.Context) (io.ReadCloser, error) {
+		return r.next.Get(ctx, cid)
+	})
+}
+
+// GetRange retrieves [start, end) for cid, serving from any cached entry
+// that covers the range.
+func (r *Retriever) GetRange(ctx context.Context, cid string, start, end uint64) (io.ReadCloser, error) {
+	if err := r.checkDenied(cid); err != nil {
+		return nil, err
+	}
+	if start < end {
+		if data, ok := r.lookup(cid, start, end, false); ok {
+			return io.NopCloser(bytes.NewReader(data)), nil
+		}
+	}
+	return r.fetch(ctx, entryKey{cid: cid, start: start, end: end}, func(ctx context.Context) (io.ReadCloser, error) {
+		return r.next.GetRange(ctx, cid, start, end)
+	})
+}
+
+// Stat passes through to the wrapped retriever after the denylist check.
+func (r *Retriever) Stat(ctx context.Context, cid string) (adapter.ObjectInfo, error) {
+	if err := r.checkDenied(cid); err != nil {
+		return adapter.ObjectInfo{}, err
+	}
+	return adapter.StatOf(ctx, r.next, cid)
+}
+
+// Purge drops every cached entry for cid.
+func (r *Retriever) Purge(cid string) {
+	r.mu.Lock()
+	r.mem.removeCID(cid)
+	var onDisk []*entry
+	if r.disk != nil {
+		onDisk = r.disk.removeCID(cid)
+	}
+	r.mu.Unlock()
+
+	removeFiles(onDisk)
+}
+
+// PurgeDenied checks every cached CID against the DenyList and purges the
+// denied ones. It returns the number of CIDs purged.
+func (r *Retriever) PurgeDenied() (int, error) {
+	if r.deny == nil {
+		return 0, nil
+	}
+	r.mu.Lock()
+	cids := r.mem.cids()
+	if r.disk != nil {
+		cids = append(cids, r.disk.cids()...)
+	}
+	r.mu.Unlock()
+
+	seen := make(map[string]bool, len(cids))
+	purged := 0
+	for _, cid := range cids {
+		if seen[cid] {
+			continue
+		}
+		seen[cid] = true
+		denied, err := r.deny.IsDenied(cid)
+		if err != nil {
+			return purged, err
+		}
+		if denied {
+			r.Purge(cid)
+			r.mu.Lock()
+			r.stats.DeniedPurges++
+			r.mu.Unlock()
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// Stats returns a snapshot of the cache counters.
+func (r *Retriever) Stats() Stats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.stats
+	s.MemoryBytes = r.mem.used
+	if r.disk != nil {
+		s.DiskBytes = r.disk.used
+	}
+	return s
+}
+
+// checkDenied purges and rejects cid if the DenyList says it is denied.
+func (r *Retriever) checkDenied(cid string) error {
+	if r.deny == nil {
+		return nil
+	}
+	denied, err := r.deny.IsDenied(cid)
+	if err != nil {
+		return fmt.Errorf("cache: denylist lookup: %w", err)
+	}
+	if !denied {
+		return nil
+	}
+	r.Purge(cid)
+	r.mu.Lock()
+	r.stats.DeniedPurges++
+	r.mu.Unlock()
+	return fmt.Errorf("%w: %s", moderation.ErrContentDenied, cid)
+}
+
+// lookup returns the bytes for [start, end) of cid (or the full object if
+// full is set) from memory, falling back to disk and promoting on a hit.
+func (r *Retriever) lookup(cid string, start, end uint64, full bool) ([]byte, bool) {
+	r.mu.Lock()
+	if e := r.mem.find(cid, start, end, full); e != nil {
+		r.stats.Hits++
+		r.mu.Unlock()
+		return slice(e, start, end, full), true
+	}
+	var onDisk *entry
+	if r.disk != nil {
+		onDisk = r.disk.find(cid, start, end, full)
+	}
+	r.mu.Unlock()
+
+	if onDisk == nil {
+		return nil, false
+	}
+	data, err := os.ReadFile(onDisk.path)
+	if err != nil || int64(len(data)) != onDisk.size {
+		// Evicted or damaged underneath us; treat as a miss.
+		return nil, false
+	}
+
+	// Promote: the memory copy replaces the disk copy.
+	r.mu.Lock()
+	r.stats.DiskHits++
+	removed := r.disk.remove(onDisk.key)
+	r.mu.Unlock()
+	if removed != nil {
+		removeFiles([]*entry{removed})
+	}
+	r.store(&entry{key: onDisk.key, size: onDisk.size, data: data})
+
+	return slice(&entry{key: onDisk.key, data: data}, start, end, full), true
+}
+
+// fetch performs a miss, sharing one upstream request between concurrent
+// callers for the same key.
+func (r *Retriever) fetch(ctx context.Context, key entryKey, get func(context.Context) (io.ReadCloser, error)) (io.ReadCloser, error) {
+	r.mu.Lock()
+	r.stats.Misses++
+	if f, ok := r.flights[key]; ok {
+		r.mu.Unlock()
+		select {
+		case <-f.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if f.err == nil {
+			return io.NopCloser(bytes.NewReader(f.data)), nil
+		}
+		if !errors.Is(f.err, errUncacheable) && !errors.Is(f.err, context.Canceled) && !errors.Is(f.err, context.DeadlineExceeded) {
+			return nil, f.err
+		}
+		// The leader's stream couldn't be shared; fetch independently.
+		return get(ctx)
+	}
+	f := &flight{done: make(chan struct{})}
+	r.flights[key] = f
+	r.mu.Unlock()
+
+	rc, stream, err := r.fetchAndBuffer(ctx, key, get)
+	f.data, f.err = rc, err
+	if stream != nil {
+		f.err = errUncacheable
+	}
+	r.mu.Lock()
+	delete(r.flights, key)
+	r.mu.Unlock()
+	close(f.done)
+
+	if err != nil {
+		return nil, err
+	}
+	if stream != nil {
+		return stream, nil
+	}
+	return io.NopCloser(bytes.NewReader(f.data)), nil
+}
+
+// fetchAndBuffer reads the upstream body into memory and caches it. If the
+// body exceeds MaxEntryBytes the buffered prefix and the remaining stream are
+// returned as a single uncached stream instead.
+func (r *Retriever) fetchAndBuffer(ctx context.Context, key entryKey, get func(context.Context) (io.ReadCloser, error)) ([]byte, io.ReadCloser, error) {
+	rc, err := get(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	var buf bytes.Buffer
+	n, err := io.Copy(&buf, io.LimitReader(rc, r.config.MaxEntryBytes+1))
+	if err != nil {
+		rc.Close()
+		return nil, nil, err
+	}
+	if n > r.config.MaxEntryBytes {
+		return nil, &streamReadCloser{
+			Reader: io.MultiReader(&buf, rc),
+			Closer: rc,
+		}, nil
+	}
+	rc.Close()
+
+	data := buf.Bytes()
+	if key.full {
+		key.end = uint64(len(data))
+	}
+	r.store(&entry{key: key, size: int64(len(data)), data: data})
+	return data, nil, nil
+}
+
+// store adds e to the memory tier and demotes evicted entries to disk.
+func (r *Retriever) store(e *entry) {
+	if e.size > r.config.MaxMemoryBytes {
+		return
+	}
+	r.mu.Lock()
+	evicted := r.mem.add(e)
+	if r.disk == nil {
+		r.stats.Evictions += uint64(len(evicted))
+	}
+	r.mu.Unlock()
+
+	if r.disk == nil {
+		return
+	}
+	for _, ev := range evicted {
+		r.demote(ev)
+	}
+}
+
+// demote writes an entry evicted from memory to the disk tier.
+func (r *Retriever) demote(e *entry) {
+	if e.size > r.config.MaxDiskBytes {
+		r.mu.Lock()
+		r.stats.Evictions++
+		r.mu.Unlock()
+		return
+	}
+	path := filepath.Join(r.config.DiskDir, diskName(e.key))
+	if err := os.WriteFile(path, e.data, 0o644); err != nil {
+		r.mu.Lock()
+		r.stats.Evictions++
+		r.mu.Unlock()
+		return
+	}
+
+	r.mu.Lock()
+	evicted := r.disk.add(&entry{key: e.key, size: e.size, path: path})
+	r.stats.Evictions += uint64(len(evicted))
+	r.mu.Unlock()
+	removeFiles(evicted)
+}
+
+// slice returns the bytes for [start, end) out of a cached entry.
+func slice(e *entry, start, end uint64, full bool) []byte {
+	if full {
+		return e.data
+	}
+	return e.data[start-e.key.start : end-e.key.start]
+}
+
+func diskName(k entryKey) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%d|%d|%t", k.cid, k.start, k.end, k.full)))
+	return hex.EncodeToString(sum[:]) + ".seg"
+}
+
+func removeFiles(entries []*entry) {
+	for _, e := range entries {
+		if e.path != "" {
+			_ = os.Remove(e.path)
+		}
+	}
+}
+
+type streamReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// Compile-time interface checks.
+var (
+	_ adapter.RetrieverAPI = (*Retriever)(nil)
+	_ adapter.Stater       = (*Retriever)(nil)
+)
@@ -0,0 +1,229 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/quriustus/filstream-curio-adapter/internal/mock"
+	"github.com/quriustus/filstream-curio-adapter/pkg/moderation"
+)
+
+// countingRetriever counts upstream calls and can hold them until released.
+type countingRetriever struct {
+	*mock.Backend
+	calls int32
+	gate  chan struct{}
+}
+
+func (c *countingRetriever) Get(ctx context.Context, cid string) (io.ReadCloser, error) {
+	atomic.AddInt32(&c.calls, 1)
+	if c.gate != nil {
+		<-c.gate
+	}
+	return c.Backend.Get(ctx, cid)
+}
+
+func (c *countingRetriever) GetRange(ctx context.Context, cid string, start, end uint64) (io.ReadCloser, error) {
+	atomic.AddInt32(&c.calls, 1)
+	if c.gate != nil {
+		<-c.gate
+	}
+	return c.Backend.GetRange(ctx, cid, start, end)
+}
+
+func readAll(t *testing.T, rc io.ReadCloser, err error) string {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestRetriever_GetHit(t *testing.T) {
+	up := &countingRetriever{Backend: mock.NewBackend()}
+	c, _ := NewRetriever(up, nil, DefaultConfig())
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		rc, err := c.Get(ctx, "bafydeadbeef")
+		if got := readAll(t, rc, err); got != "hello filstream" {
+			t.Fatalf("unexpected data: %q", got)
+		}
+	}
+	if up.calls != 1 {
+		t.Fatalf("expected 1 upstream call, got %d", up.calls)
+	}
+	if s := c.Stats(); s.Hits != 2 || s.Misses != 1 {
+		t.Fatalf("unexpected stats: %+v", s)
+	}
+}
+
+func TestRetriever_SubRangeFromCachedRange(t *testing.T) {
+	up := &countingRetriever{Backend: mock.NewBackend()}
+	c, _ := NewRetriever(up, nil, DefaultConfig())
+	ctx := context.Background()
+
+	// Cache [0, 15) then serve [6, 15) and [0, 5) from it.
+	rc, err := c.GetRange(ctx, "bafydeadbeef", 0, 15)
+	readAll(t, rc, err)
+
+	rc, err = c.GetRange(ctx, "bafydeadbeef", 6, 15)
+	if got := readAll(t, rc, err); got != "filstream" {
+		t.Fatalf("unexpected sub-range: %q", got)
+	}
+	rc, err = c.GetRange(ctx, "bafydeadbeef", 0, 5)
+	if got := readAll(t, rc, err); got != "hello" {
+		t.Fatalf("unexpected sub-range: %q", got)
+	}
+	if up.calls != 1 {
+		t.Fatalf("expected 1 upstream call, got %d", up.calls)
+	}
+
+	// A range extending past the cached one is a miss.
+	rc, err = c.GetRange(ctx, "bafy5678chunk", 0, 10)
+	readAll(t, rc, err)
+	if up.calls != 2 {
+		t.Fatalf("expected 2 upstream calls, got %d", up.calls)
+	}
+}
+
+func TestRetriever_RangeFromFullObject(t *testing.T) {
+	up := &countingRetriever{Backend: mock.NewBackend()}
+	c, _ := NewRetriever(up, nil, DefaultConfig())
+	ctx := context.Background()
+
+	rc, err := c.Get(ctx, "bafydeadbeef")
+	readAll(t, rc, err)
+	rc, err = c.GetRange(ctx, "bafydeadbeef", 1, 4)
+	if got := readAll(t, rc, err); got != "ell" {
+		t.Fatalf("unexpected data: %q", got)
+	}
+	if up.calls != 1 {
+		t.Fatalf("expected range served from full object, got %d calls", up.calls)
+	}
+}
+
+func TestRetriever_SingleFlight(t *testing.T) {
+	up := &countingRetriever{Backend: mock.NewBackend(), gate: make(chan struct{})}
+	c, _ := NewRetriever(up, nil, DefaultConfig())
+
+	var wg sync.WaitGroup
+	results := make([]string, 8)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			rc, err := c.GetRange(context.Background(), "bafydeadbeef", 0, 5)
+			if err != nil {
+				return
+			}
+			defer rc.Close()
+			data, _ := io.ReadAll(rc)
+			results[i] = string(data)
+		}(i)
+	}
+	// Let the goroutines pile up on the same flight, then release upstream.
+	for atomic.LoadInt32(&up.calls) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	close(up.gate)
+	wg.Wait()
+
+	if up.calls != 1 {
+		t.Fatalf("expected concurrent misses deduplicated, got %d upstream calls", up.calls)
+	}
+	for i, r := range results {
+		if r != "hello" {
+			t.Fatalf("result %d: unexpected data %q", i, r)
+		}
+	}
+}
+
+func TestRetriever_LRUEvictionAndDiskTier(t *testing.T) {
+	up := &countingRetriever{Backend: mock.NewBackend()}
+	up.AddObject("bafy-a", bytes.Repeat([]byte("a"), 100))
+	up.AddObject("bafy-b", bytes.Repeat([]byte("b"), 100))
+	cfg := Config{MaxMemoryBytes: 150, MaxEntryBytes: 1000, DiskDir: t.TempDir(), MaxDiskBytes: 1000}
+	c, err := NewRetriever(up, nil, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	rc, err := c.Get(ctx, "bafy-a")
+	readAll(t, rc, err)
+	rc, err = c.Get(ctx, "bafy-b") // evicts bafy-a to disk
+	readAll(t, rc, err)
+
+	s := c.Stats()
+	if s.MemoryBytes != 100 || s.DiskBytes != 100 {
+		t.Fatalf("expected one entry per tier, got %+v", s)
+	}
+
+	rc, err = c.Get(ctx, "bafy-a")
+	if got := readAll(t, rc, err); got != string(bytes.Repeat([]byte("a"), 100)) {
+		t.Fatal("unexpected data from disk tier")
+	}
+	if up.calls != 2 {
+		t.Fatalf("expected disk hit, got %d upstream calls", up.calls)
+	}
+	if s := c.Stats(); s.DiskHits != 1 {
+		t.Fatalf("expected 1 disk hit, got %+v", s)
+	}
+}
+
+func TestRetriever_OversizedPassthrough(t *testing.T) {
+	up := &countingRetriever{Backend: mock.NewBackend()}
+	c, _ := NewRetriever(up, nil, Config{MaxMemoryBytes: 1 << 20, MaxEntryBytes: 4})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		rc, err := c.Get(ctx, "bafydeadbeef")
+		if got := readAll(t, rc, err); got != "hello filstream" {
+			t.Fatalf("unexpected data: %q", got)
+		}
+	}
+	if up.calls != 2 {
+		t.Fatalf("expected oversized object not cached, got %d calls", up.calls)
+	}
+}
+
+func TestRetriever_DeniedContentPurged(t *testing.T) {
+	up := &countingRetriever{Backend: mock.NewBackend()}
+	dl := moderation.NewMockDenyList()
+	c, _ := NewRetriever(up, dl, DefaultConfig())
+	ctx := context.Background()
+
+	rc, err := c.Get(ctx, "bafydeadbeef")
+	readAll(t, rc, err)
+	rc, err = c.Get(ctx, "bafy5678chunk")
+	readAll(t, rc, err)
+
+	_ = dl.Add("bafydeadbeef", "copyright")
+	if _, err := c.Get(ctx, "bafydeadbeef"); !errors.Is(err, moderation.ErrContentDenied) {
+		t.Fatalf("expected ErrContentDenied, got %v", err)
+	}
+	if s := c.Stats(); s.MemoryBytes != 256*1024 {
+		t.Fatalf("expected denied entry purged, got %+v", s)
+	}
+
+	_ = dl.Add("bafy5678chunk", "abuse")
+	n, err := c.PurgeDenied()
+	if err != nil || n != 1 {
+		t.Fatalf("expected 1 purged, got %d (%v)", n, err)
+	}
+	if s := c.Stats(); s.MemoryBytes != 0 {
+		t.Fatalf("expected empty cache, got %+v", s)
+	}
+}
@@ -0,0 +1,126 @@
+package cache
+
+import (
+	"container/list"
+)
+
+// entryKey identifies a cached byte range [start, end) of a CID. Full-object
+// entries (from Get) have full set and end equal to the object size.
+type entryKey struct {
+	cid   string
+	start uint64
+	end   uint64
+	full  bool
+}
+
+type entry struct {
+	key  entryKey
+	size int64
+	data []byte // memory tier
+	path string // disk tier
+}
+
+// tier is a byte-budget LRU of cache entries, indexed by CID so range
+// lookups only scan entries for the requested object.
+type tier struct {
+	ll    *list.List // front = most recently used
+	items map[entryKey]*list.Element
+	byCID map[string]map[entryKey]*list.Element
+	used  int64
+	max   int64
+}
+
+func newTier(maxBytes int64) *tier {
+	return &tier{
+		ll:    list.New(),
+		items: make(map[entryKey]*list.Element),
+		byCID: make(map[string]map[entryKey]*list.Element),
+		max:   maxBytes,
+	}
+}
+
+// find returns the smallest entry that covers [start, end) of cid. If full is
+// set, only full-object entries match. The entry is marked recently used.
+func (t *tier) find(cid string, start, end uint64, full bool) *entry {
+	var best *list.Element
+	for k, el := range t.byCID[cid] {
+		if full && !k.full {
+			continue
+		}
+		if !full && (k.start > start || k.end < end) {
+			continue
+		}
+		if best == nil || el.Value.(*entry).size < best.Value.(*entry).size {
+			best = el
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	t.ll.MoveToFront(best)
+	return best.Value.(*entry)
+}
+
+// add inserts e and returns the entries evicted to stay within budget.
+func (t *tier) add(e *entry) []*entry {
+	if old, ok := t.items[e.key]; ok {
+		t.unlink(old)
+	}
+	el := t.ll.PushFront(e)
+	t.items[e.key] = el
+	if t.byCID[e.key.cid] == nil {
+		t.byCID[e.key.cid] = make(map[entryKey]*list.Element)
+	}
+	t.byCID[e.key.cid][e.key] = el
+	t.used += e.size
+
+	var evicted []*entry
+	for t.used > t.max && t.ll.Len() > 0 {
+		back := t.ll.Back()
+		t.unlink(back)
+		evicted = append(evicted, back.Value.(*entry))
+	}
+	return evicted
+}
+
+// remove drops a single entry.
+func (t *tier) remove(k entryKey) *entry {
+	el, ok := t.items[k]
+	if !ok {
+		return nil
+	}
+	t.unlink(el)
+	return el.Value.(*entry)
+}
+
+// removeCID drops every entry for cid.
+func (t *tier) removeCID(cid string) []*entry {
+	var out []*entry
+	for _, el := range t.byCID[cid] {
+		t.unlink(el)
+		out = append(out, el.Value.(*entry))
+	}
+	return out
+}
+
+// cids returns every CID with at least one entry.
+func (t *tier) cids() []string {
+	out := make([]string, 0, len(t.byCID))
+	for cid := range t.byCID {
+		out = append(out, cid)
+	}
+	return out
+}
+
+func (t *tier) unlink(el *list.Element) {
+	e := el.Value.(*entry)
+	t.ll.Remove(el)
+	delete(t.items, e.key)
+	if m := t.byCID[e.key.cid]; m != nil {
+		delete(m, e.key)
+		if len(m) == 0 {
+			delete(t.byCID, e.key.cid)
+		}
+	}
+	t.used -= e.size
+}
@@ -0,0 +1,280 @@
+// Package curio implements the adapter interfaces against a real Curio node
+// over its HTTP retrieval endpoints.
+package curio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/quriustus/filstream-curio-adapter/pkg/adapter"
+	"github.com/quriustus/filstream-curio-adapter/pkg/verify"
+)
+
+// pieceCIDPrefix identifies CommP piece CIDs (fil-commitment-unsealed,
+// sha2-256-trunc254-padded), which Curio serves from /piece/ rather than the
+// trustless IPFS gateway.
+const pieceCIDPrefix = "baga6ea4sea"
+
+// Sentinel errors returned by Client. Use errors.Is to match them; server
+// failures are wrapped in *StatusError so the status code stays available.
+// ErrNotFound and ErrRangeNotSatisfiable are the shared adapter errors.
+var (
+	ErrNotFound            = adapter.ErrNotFound
+	ErrRangeNotSatisfiable = adapter.ErrRangeNotSatisfiable
+	ErrServer              = errors.New("curio: server error")
+	ErrInvalidRange        = errors.New("curio: invalid range")
+
+	// ErrUnsupportedCID is returned for CIDs the client can't serve
+	// byte-exact. Only raw-codec CIDs and piece CIDs are accepted: the
+	// trustless gateway's format=raw returns just the root block of a
+	// dag-pb DAG, which would serve truncated content.
+	ErrUnsupportedCID = errors.New("curio: unsupported cid")
+
+	// ErrBadResponse is returned when a response doesn't match the
+	// request, e.g. a 206 for a different range.
+	ErrBadResponse = errors.New("curio: malformed response")
+)
+
+// StatusError describes an unexpected HTTP response from Curio.
+type StatusError struct {
+	StatusCode int
+	CID        string
+	Body       string // first bytes of the response body, for diagnostics
+}
+
+func (e *StatusError) Error() string {
+	msg := fmt.Sprintf("curio: unexpected status %d for %s", e.StatusCode, e.CID)
+	if e.Body != "" {
+		msg += ": " + e.Body
+	}
+	return msg
+}
+
+// Unwrap maps the status code onto the package sentinel errors.
+func (e *StatusError) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case e.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		return ErrRangeNotSatisfiable
+	case e.StatusCode >= 500:
+		return ErrServer
+	}
+	return nil
+}
+
+// Client retrieves content from a single Curio node. It is safe for
+// concurrent use.
+type Client struct {
+	baseURL *url.URL
+	http    *http.Client
+}
+
+// NewClient creates a client for the Curio node at baseURL
+// (e.g. "http://curio.example:12310"). If httpClient is nil a client with a
+// 60-second timeout is used.
+func NewClient(baseURL string, httpClient *http.Client) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("curio: parse base url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("curio: unsupported base url scheme %q", u.Scheme)
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 60 * time.Second}
+	}
+	return &Client{baseURL: u, http: httpClient}, nil
+}
+
+// Get retrieves the full content for the given CID.
+func (c *Client) Get(ctx context.Context, cid string) (io.ReadCloser, error) {
+	req, err := c.newRequest(ctx, cid)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("curio: get %s: %w", cid, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(resp, cid)
+	}
+	return resp.Body, nil
+}
+
+// GetRange retrieves the byte range [start, end) for the given CID. The
+// half-open range is sent as the inclusive HTTP header "bytes=start-(end-1)".
+func (c *Client) GetRange(ctx context.Context, cid string, start, end uint64) (io.ReadCloser, error) {
+	if start >= end {
+		return nil, fmt.Errorf("%w: [%d, %d)", ErrInvalidRange, start, end)
+	}
+	req, err := c.newRequest(ctx, cid)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end-1))
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("curio: get %s [%d, %d): %w", cid, start, end, err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		first, last, err := parseContentRange(resp.Header.Get("Content-Range"))
+		if err != nil || first != start || last < first {
+			resp.Body.Close()
+			return nil, fmt.Errorf("%w: Content-Range %q for [%d, %d) of %s",
+				ErrBadResponse, resp.Header.Get("Content-Range"), start, end, cid)
+		}
+		if last >= end {
+			// Longer than asked for; trim to the requested range.
+			return &limitedReadCloser{
+				Reader: io.LimitReader(resp.Body, int64(end-start)),
+				Closer: resp.Body,
+			}, nil
+		}
+		return resp.Body, nil
+	case http.StatusOK:
+		// Server ignored the Range header; slice the full body ourselves.
+		if _, err := io.CopyN(io.Discard, resp.Body, int64(start)); err != nil {
+			resp.Body.Close()
+			if errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("%w: [%d, %d) for %s", ErrRangeNotSatisfiable, start, end, cid)
+			}
+			return nil, fmt.Errorf("curio: skip to offset %d: %w", start, err)
+		}
+		return &limitedReadCloser{
+			Reader: io.LimitReader(resp.Body, int64(end-start)),
+			Closer: resp.Body,
+		}, nil
+	}
+	return nil, statusError(resp, cid)
+}
+
+// Stat issues a HEAD request and reports the object's size from
+// Content-Length. The codec is derived from the CID.
+func (c *Client) Stat(ctx context.Context, cid string) (adapter.ObjectInfo, error) {
+	req, err := c.newRequest(ctx, cid)
+	if err != nil {
+		return adapter.ObjectInfo{}, err
+	}
+	req.Method = http.MethodHead
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return adapter.ObjectInfo{}, fmt.Errorf("curio: stat %s: %w", cid, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return adapter.ObjectInfo{}, statusError(resp, cid)
+	}
+	resp.Body.Close()
+	if resp.ContentLength < 0 {
+		return adapter.ObjectInfo{}, fmt.Errorf("curio: stat %s: server did not report Content-Length", cid)
+	}
+	return adapter.ObjectInfo{
+		CID:   cid,
+		Size:  uint64(resp.ContentLength),
+		Codec: codecName(cid),
+	}, nil
+}
+
+// parseContentRange parses "bytes first-last/size" (size may be "*").
+func parseContentRange(h string) (first, last uint64, err error) {
+	spec, ok := strings.CutPrefix(h, "bytes ")
+	if !ok {
+		return 0, 0, fmt.Errorf("curio: bad Content-Range %q", h)
+	}
+	rng, _, ok := strings.Cut(spec, "/")
+	if !ok {
+		return 0, 0, fmt.Errorf("curio: bad Content-Range %q", h)
+	}
+	a, b, ok := strings.Cut(rng, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("curio: bad Content-Range %q", h)
+	}
+	if first, err = strconv.ParseUint(a, 10, 64); err != nil {
+		return 0, 0, fmt.Errorf("curio: bad Content-Range %q", h)
+	}
+	if last, err = strconv.ParseUint(b, 10, 64); err != nil {
+		return 0, 0, fmt.Errorf("curio: bad Content-Range %q", h)
+	}
+	return first, last, nil
+}
+
+// codecName names the content codec of cid, or "" if it cannot be parsed.
+func codecName(cid string) string {
+	if strings.HasPrefix(cid, pieceCIDPrefix) {
+		return "fil-commitment-unsealed"
+	}
+	parsed, err := verify.ParseCID(cid)
+	if err != nil {
+		return ""
+	}
+	switch parsed.Codec {
+	case verify.CodecRaw:
+		return "raw"
+	case verify.CodecDagPB:
+		return "dag-pb"
+	}
+	return ""
+}
+
+// newRequest builds a GET request for the retrieval endpoint serving cid.
+// Piece CIDs go to /piece/; other CIDs must be raw-codec (a single block)
+// and go to /ipfs/ with format=raw.
+func (c *Client) newRequest(ctx context.Context, cid string) (*http.Request, error) {
+	if cid == "" {
+		return nil, errors.New("curio: empty cid")
+	}
+	u := *c.baseURL
+	if strings.HasPrefix(cid, pieceCIDPrefix) {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/piece/" + url.PathEscape(cid)
+	} else {
+		parsed, err := verify.ParseCID(cid)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrUnsupportedCID, cid, err)
+		}
+		if parsed.Codec != verify.CodecRaw {
+			return nil, fmt.Errorf("%w: %s is %s, only raw and piece CIDs can be served", ErrUnsupportedCID, cid, codecName(cid))
+		}
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/ipfs/" + url.PathEscape(cid)
+		u.RawQuery = "format=raw"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("curio: build request: %w", err)
+	}
+	return req, nil
+}
+
+// statusError drains and closes resp, returning a *StatusError.
+func statusError(resp *http.Response, cid string) error {
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return &StatusError{
+		StatusCode: resp.StatusCode,
+		CID:        cid,
+		Body:       strings.TrimSpace(string(body)),
+	}
+}
+
+type limitedReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// Compile-time interface checks.
+var (
+	_ adapter.RetrieverAPI = (*Client)(nil)
+	_ adapter.Stater       = (*Client)(nil)
+)
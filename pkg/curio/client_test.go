@@ -0,0 +1,240 @@
+package curio
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quriustus/filstream-curio-adapter/pkg/verify"
+)
+
+// rawCID returns the raw-codec sha2-256 CID of data.
+func rawCID(data string) string {
+	c, err := verify.Sum(verify.CodecRaw, verify.HashSHA2_256, []byte(data))
+	if err != nil {
+		panic(err)
+	}
+	return c.String()
+}
+
+var (
+	helloCID   = rawCID("hello filstream")
+	missingCID = rawCID("missing")
+	boomCID    = rawCID("boom")
+)
+
+// newTestServer serves objects from /ipfs/{cid} and /piece/{cid} with
+// standard Range handling via http.ServeContent.
+func newTestServer(t *testing.T, objects map[string][]byte) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var cid string
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/ipfs/"):
+			cid = strings.TrimPrefix(r.URL.Path, "/ipfs/")
+		case strings.HasPrefix(r.URL.Path, "/piece/"):
+			cid = strings.TrimPrefix(r.URL.Path, "/piece/")
+		}
+		if cid == boomCID {
+			http.Error(w, "internal failure", http.StatusInternalServerError)
+			return
+		}
+		data, ok := objects[cid]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeContent(w, r, cid, time.Time{}, bytes.NewReader(data))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestClient(t *testing.T, srv *httptest.Server) *Client {
+	t.Helper()
+	c, err := NewClient(srv.URL, srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestClient_Get(t *testing.T) {
+	srv := newTestServer(t, map[string][]byte{helloCID: []byte("hello filstream")})
+	c := newTestClient(t, srv)
+
+	rc, err := c.Get(context.Background(), helloCID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+
+	data, _ := io.ReadAll(rc)
+	if string(data) != "hello filstream" {
+		t.Fatalf("unexpected data: %q", data)
+	}
+}
+
+func TestClient_GetPiece(t *testing.T) {
+	piece := "baga6ea4seaqpiece"
+	srv := newTestServer(t, map[string][]byte{piece: []byte("piece-bytes")})
+	c := newTestClient(t, srv)
+
+	rc, err := c.Get(context.Background(), piece)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+
+	data, _ := io.ReadAll(rc)
+	if string(data) != "piece-bytes" {
+		t.Fatalf("unexpected data: %q", data)
+	}
+}
+
+func TestClient_GetRange(t *testing.T) {
+	srv := newTestServer(t, map[string][]byte{helloCID: []byte("hello filstream")})
+	c := newTestClient(t, srv)
+
+	// [6, 15) — End is exclusive.
+	rc, err := c.GetRange(context.Background(), helloCID, 6, 15)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+
+	data, _ := io.ReadAll(rc)
+	if string(data) != "filstream" {
+		t.Fatalf("unexpected data: %q", data)
+	}
+}
+
+func TestClient_GetRangeServerIgnoresRange(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello filstream"))
+	}))
+	defer srv.Close()
+	c := newTestClient(t, srv)
+
+	rc, err := c.GetRange(context.Background(), helloCID, 0, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+
+	data, _ := io.ReadAll(rc)
+	if string(data) != "hello" {
+		t.Fatalf("unexpected data: %q", data)
+	}
+}
+
+func TestClient_Errors(t *testing.T) {
+	srv := newTestServer(t, map[string][]byte{helloCID: []byte("hello filstream")})
+	c := newTestClient(t, srv)
+	ctx := context.Background()
+
+	if _, err := c.Get(ctx, missingCID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	if _, err := c.GetRange(ctx, helloCID, 100, 200); !errors.Is(err, ErrRangeNotSatisfiable) {
+		t.Fatalf("expected ErrRangeNotSatisfiable, got %v", err)
+	}
+
+	_, err := c.Get(ctx, boomCID)
+	if !errors.Is(err, ErrServer) {
+		t.Fatalf("expected ErrServer, got %v", err)
+	}
+	var se *StatusError
+	if !errors.As(err, &se) || se.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("expected *StatusError with 500, got %v", err)
+	}
+
+	if _, err := c.GetRange(ctx, helloCID, 5, 3); !errors.Is(err, ErrInvalidRange) {
+		t.Fatalf("expected ErrInvalidRange, got %v", err)
+	}
+}
+
+func TestNewClient_InvalidURL(t *testing.T) {
+	if _, err := NewClient("ftp://curio.example", nil); err == nil {
+		t.Fatal("expected error for unsupported scheme")
+	}
+}
+
+func TestClient_Stat(t *testing.T) {
+	srv := newTestServer(t, map[string][]byte{helloCID: []byte("hello filstream")})
+	c := newTestClient(t, srv)
+
+	info, err := c.Stat(context.Background(), helloCID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size != 15 {
+		t.Fatalf("expected size 15, got %d", info.Size)
+	}
+
+	if _, err := c.Stat(context.Background(), missingCID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestClient_RejectsNonRawCIDs(t *testing.T) {
+	srv := newTestServer(t, nil)
+	c := newTestClient(t, srv)
+
+	dagpb, _ := verify.Sum(verify.CodecDagPB, verify.HashSHA2_256, []byte("root block"))
+	for _, cid := range []string{dagpb.String(), "bafynotacid"} {
+		if _, err := c.Get(context.Background(), cid); !errors.Is(err, ErrUnsupportedCID) {
+			t.Fatalf("%s: expected ErrUnsupportedCID, got %v", cid, err)
+		}
+	}
+}
+
+func TestClient_GetRangeChecksContentRange(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   string
+		err    error
+	}{
+		{"exact", "bytes 6-14/15", "filstream", nil},
+		{"longer than asked", "bytes 6-14/15", "fil", nil},
+		{"wrong start", "bytes 0-8/15", "", ErrBadResponse},
+		{"missing", "", "", ErrBadResponse},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.header != "" {
+					w.Header().Set("Content-Range", tt.header)
+				}
+				w.WriteHeader(http.StatusPartialContent)
+				_, _ = w.Write([]byte("hello filstream"[6:]))
+			}))
+			defer srv.Close()
+			c := newTestClient(t, srv)
+
+			end := uint64(6 + len(tt.want))
+			if tt.err != nil {
+				end = 15
+			}
+			rc, err := c.GetRange(context.Background(), helloCID, 6, end)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected %v, got %v", tt.err, err)
+			}
+			if err != nil {
+				return
+			}
+			defer rc.Close()
+			if data, _ := io.ReadAll(rc); string(data) != tt.want {
+				t.Fatalf("unexpected data: %q", data)
+			}
+		})
+	}
+}
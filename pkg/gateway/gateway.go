@@ -0,0 +1,281 @@
+// Package gateway serves adapter.RetrieverAPI content over HTTP with
+// standard Range semantics, so FilStream players can stream directly.
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/quriustus/filstream-curio-adapter/pkg/adapter"
+	"github.com/quriustus/filstream-curio-adapter/pkg/moderation"
+)
+
+// Config holds gateway settings.
+type Config struct {
+	// MaxBufferBytes bounds how much of an object the gateway will buffer
+	// to answer a range whose bounds depend on the object size. Larger
+	// objects are served in full with 200.
+	MaxBufferBytes int64
+}
+
+// DefaultConfig returns a Config with sensible defaults.
+func DefaultConfig() Config {
+	return Config{MaxBufferBytes: 64 << 20}
+}
+
+// Server is an http.Handler serving /ipfs/{cid} and /content/{cid}.
+type Server struct {
+	retriever adapter.RetrieverAPI
+	deny      moderation.DenyList
+	config    Config
+}
+
+// NewServer creates a gateway over r. deny may be nil to disable moderation
+// checks.
+func NewServer(r adapter.RetrieverAPI, deny moderation.DenyList, cfg Config) *Server {
+	return &Server{retriever: r, deny: deny, config: cfg}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	cid, ok := cidFromPath(r.URL.Path)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	if s.deny != nil {
+		denied, err := s.deny.IsDenied(cid)
+		if err != nil {
+			http.Error(w, "denylist unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		if denied {
+			http.Error(w, "unavailable for legal reasons", http.StatusUnavailableForLegalReasons)
+			return
+		}
+	}
+
+	// Content addressing makes the CID a perfect strong validator.
+	etag := `"` + cid + `"`
+	h := w.Header()
+	h.Set("ETag", etag)
+	h.Set("Accept-Ranges", "bytes")
+	h.Set("Cache-Control", "public, max-age=31536000, immutable")
+	h.Set("Content-Type", "application/octet-stream")
+	if inm := r.Header.Get("If-None-Match"); inm != "" && etagMatches(inm, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	rangeHeader := r.Header.Get("Range")
+	if ir := r.Header.Get("If-Range"); ir != "" && ir != etag {
+		rangeHeader = ""
+	}
+	br, ok, err := parseRange(rangeHeader)
+	switch {
+	case err != nil:
+		h.Set("Content-Range", "bytes */*")
+		http.Error(w, "range not satisfiable", http.StatusRequestedRangeNotSatisfiable)
+		return
+	case !ok:
+		s.serveFull(w, r, cid)
+	case br.hasLast && br.last-br.first < uint64(s.config.MaxBufferBytes):
+		// Bounded ranges need no size; skip the Stat round trip.
+		s.serveBoundedRange(w, r, cid, br)
+	case s.serveStatRange(w, r, cid, br):
+	default:
+		s.serveBufferedRange(w, r, cid, br)
+	}
+}
+
+// serveFull streams the whole object with 200.
+func (s *Server) serveFull(w http.ResponseWriter, r *http.Request, cid string) {
+	rc, err := s.retriever.Get(r.Context(), cid)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer rc.Close()
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodGet {
+		_, _ = io.Copy(w, rc)
+	}
+}
+
+// serveStatRange resolves the range with adapter.Stater and streams a
+// single GetRange. It returns false, having written nothing, if the
+// retriever cannot Stat.
+func (s *Server) serveStatRange(w http.ResponseWriter, r *http.Request, cid string, br byteRange) bool {
+	info, err := adapter.StatOf(r.Context(), s.retriever, cid)
+	if errors.Is(err, adapter.ErrStatUnsupported) {
+		return false
+	}
+	if err != nil {
+		writeError(w, err)
+		return true
+	}
+
+	start, end, err := br.resolve(info.Size)
+	if err != nil {
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", info.Size))
+		http.Error(w, "range not satisfiable", http.StatusRequestedRangeNotSatisfiable)
+		return true
+	}
+	rc, err := s.retriever.GetRange(r.Context(), cid, start, end)
+	if err != nil {
+		writeError(w, err)
+		return true
+	}
+	defer rc.Close()
+
+	h := w.Header()
+	h.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end-1, info.Size))
+	h.Set("Content-Length", strconv.FormatUint(end-start, 10))
+	w.WriteHeader(http.StatusPartialContent)
+	if r.Method == http.MethodGet {
+		_, _ = io.Copy(w, rc)
+	}
+	return true
+}
+
+// serveBoundedRange handles "bytes=a-b" with a single GetRange(a, b+1). The
+// object size is only learned if the response comes back short.
+func (s *Server) serveBoundedRange(w http.ResponseWriter, r *http.Request, cid string, br byteRange) {
+	want := br.last - br.first + 1
+	rc, err := s.retriever.GetRange(r.Context(), cid, br.first, br.last+1)
+	if err != nil {
+		if errors.Is(err, adapter.ErrRangeNotSatisfiable) {
+			// Backends may reject an end past the object size; resolve the
+			// range against the real size instead.
+			s.serveBufferedRange(w, r, cid, br)
+			return
+		}
+		writeError(w, err)
+		return
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, int64(want)))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	n := uint64(len(data))
+	if n == 0 {
+		w.Header().Set("Content-Range", "bytes */*")
+		http.Error(w, "range not satisfiable", http.StatusRequestedRangeNotSatisfiable)
+		return
+	}
+	total := "*"
+	if n < want {
+		total = strconv.FormatUint(br.first+n, 10)
+	}
+	writePartial(w, r, data, br.first, br.first+n, total)
+}
+
+// serveBufferedRange fetches the full object to resolve suffix and
+// open-ended ranges against its size.
+func (s *Server) serveBufferedRange(w http.ResponseWriter, r *http.Request, cid string, br byteRange) {
+	rc, err := s.retriever.Get(r.Context(), cid)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer rc.Close()
+
+	var buf bytes.Buffer
+	n, err := io.Copy(&buf, io.LimitReader(rc, s.config.MaxBufferBytes+1))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if n > s.config.MaxBufferBytes {
+		// Too large to resolve in memory; ignoring Range is permitted.
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			_, _ = io.Copy(w, io.MultiReader(&buf, rc))
+		}
+		return
+	}
+
+	size := uint64(n)
+	start, end, err := br.resolve(size)
+	if err != nil {
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+		http.Error(w, "range not satisfiable", http.StatusRequestedRangeNotSatisfiable)
+		return
+	}
+	writePartial(w, r, buf.Bytes()[start:end], start, end, strconv.FormatUint(size, 10))
+}
+
+// writePartial writes a 206 for the half-open range [start, end).
+func writePartial(w http.ResponseWriter, r *http.Request, data []byte, start, end uint64, total string) {
+	h := w.Header()
+	h.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%s", start, end-1, total))
+	h.Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(http.StatusPartialContent)
+	if r.Method == http.MethodGet {
+		_, _ = w.Write(data)
+	}
+}
+
+// writeError maps retrieval errors onto HTTP status codes.
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case isDenied(err):
+		http.Error(w, "unavailable for legal reasons", http.StatusUnavailableForLegalReasons)
+	case isNotFound(err):
+		http.Error(w, "not found", http.StatusNotFound)
+	case errors.Is(err, adapter.ErrRangeNotSatisfiable):
+		w.Header().Set("Content-Range", "bytes */*")
+		http.Error(w, "range not satisfiable", http.StatusRequestedRangeNotSatisfiable)
+	case isContextErr(err):
+		http.Error(w, "request cancelled", http.StatusGatewayTimeout)
+	default:
+		http.Error(w, "upstream retrieval failed", http.StatusBadGateway)
+	}
+}
+
+func isDenied(err error) bool   { return errors.Is(err, moderation.ErrContentDenied) }
+func isNotFound(err error) bool { return errors.Is(err, adapter.ErrNotFound) }
+
+func isContextErr(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// cidFromPath extracts the CID from /ipfs/{cid} or /content/{cid}.
+func cidFromPath(p string) (string, bool) {
+	for _, prefix := range []string{"/ipfs/", "/content/"} {
+		if strings.HasPrefix(p, prefix) {
+			cid := strings.TrimPrefix(p, prefix)
+			if cid == "" || strings.Contains(cid, "/") {
+				return "", false
+			}
+			return cid, true
+		}
+	}
+	return "", false
+}
+
+// etagMatches reports whether an If-None-Match header matches etag.
+func etagMatches(header, etag string) bool {
+	for _, v := range strings.Split(header, ",") {
+		v = strings.TrimSpace(v)
+		if v == "*" || strings.TrimPrefix(v, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
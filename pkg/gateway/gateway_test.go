@@ -0,0 +1,186 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/quriustus/filstream-curio-adapter/internal/mock"
+	"github.com/quriustus/filstream-curio-adapter/pkg/adapter"
+	"github.com/quriustus/filstream-curio-adapter/pkg/moderation"
+)
+
+func newTestServer() (*Server, *moderation.MockDenyList) {
+	dl := moderation.NewMockDenyList()
+	return NewServer(mock.NewBackend(), dl, DefaultConfig()), dl
+}
+
+func do(s *Server, method, path string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestGateway_FullObject(t *testing.T) {
+	s, _ := newTestServer()
+	for _, path := range []string{"/ipfs/bafydeadbeef", "/content/bafydeadbeef"} {
+		rec := do(s, http.MethodGet, path, nil)
+		if rec.Code != http.StatusOK || rec.Body.String() != "hello filstream" {
+			t.Fatalf("%s: got %d %q", path, rec.Code, rec.Body.String())
+		}
+		if rec.Header().Get("ETag") != `"bafydeadbeef"` {
+			t.Fatalf("unexpected ETag %q", rec.Header().Get("ETag"))
+		}
+	}
+}
+
+// noStat hides the mock's Stat so the gateway must learn sizes itself.
+type noStat struct {
+	adapter.RetrieverAPI
+}
+
+func TestGateway_Ranges(t *testing.T) {
+	tests := []struct {
+		rng      string
+		wantCode int
+		wantBody string
+		withStat string // expected Content-Range when the retriever can Stat
+		noStat   string // expected Content-Range when it cannot
+	}{
+		// Inclusive HTTP 6-14 maps to adapter [6, 15).
+		{"bytes=6-14", http.StatusPartialContent, "filstream", "bytes 6-14/*", "bytes 6-14/*"},
+		{"bytes=0-0", http.StatusPartialContent, "h", "bytes 0-0/*", "bytes 0-0/*"},
+		{"bytes=6-", http.StatusPartialContent, "filstream", "bytes 6-14/15", "bytes 6-14/15"},
+		{"bytes=-6", http.StatusPartialContent, "stream", "bytes 9-14/15", "bytes 9-14/15"},
+		{"bytes=10-100", http.StatusPartialContent, "tream", "bytes 10-14/15", "bytes 10-14/15"},
+		{"bytes=15-", http.StatusRequestedRangeNotSatisfiable, "", "bytes */15", "bytes */15"},
+		{"bytes=0-1,4-5", http.StatusOK, "hello filstream", "", ""},
+		{"bytes=0-18446744073709551615", http.StatusPartialContent, "hello filstream", "bytes 0-14/15", "bytes 0-14/15"},
+		{"bytes=6-18446744073709551614", http.StatusPartialContent, "filstream", "bytes 6-14/15", "bytes 6-14/15"},
+	}
+	servers := map[string]*Server{
+		"stat":   NewServer(mock.NewBackend(), nil, DefaultConfig()),
+		"nostat": NewServer(noStat{mock.NewBackend()}, nil, DefaultConfig()),
+	}
+	for name, s := range servers {
+		for _, tt := range tests {
+			rec := do(s, http.MethodGet, "/ipfs/bafydeadbeef", map[string]string{"Range": tt.rng})
+			if rec.Code != tt.wantCode {
+				t.Fatalf("%s %s: code %d, want %d", name, tt.rng, rec.Code, tt.wantCode)
+			}
+			if tt.wantCode != http.StatusRequestedRangeNotSatisfiable && rec.Body.String() != tt.wantBody {
+				t.Fatalf("%s %s: body %q, want %q", name, tt.rng, rec.Body.String(), tt.wantBody)
+			}
+			want := tt.withStat
+			if name == "nostat" {
+				want = tt.noStat
+			}
+			if got := rec.Header().Get("Content-Range"); got != want {
+				t.Fatalf("%s %s: Content-Range %q, want %q", name, tt.rng, got, want)
+			}
+		}
+	}
+}
+
+// countingStat counts Stat calls on the wrapped mock.
+type countingStat struct {
+	*mock.Backend
+	stats int
+}
+
+func (c *countingStat) Stat(ctx context.Context, cid string) (adapter.ObjectInfo, error) {
+	c.stats++
+	return c.Backend.Stat(ctx, cid)
+}
+
+func TestGateway_StatOnlyForUnboundedRanges(t *testing.T) {
+	rt := &countingStat{Backend: mock.NewBackend()}
+	s := NewServer(rt, nil, DefaultConfig())
+
+	do(s, http.MethodGet, "/ipfs/bafydeadbeef", map[string]string{"Range": "bytes=0-4"})
+	if rt.stats != 0 {
+		t.Fatalf("bounded range issued %d Stat calls", rt.stats)
+	}
+	do(s, http.MethodGet, "/ipfs/bafydeadbeef", map[string]string{"Range": "bytes=6-"})
+	do(s, http.MethodGet, "/ipfs/bafydeadbeef", map[string]string{"Range": "bytes=-6"})
+	if rt.stats != 2 {
+		t.Fatalf("expected 2 Stat calls for open-ended and suffix ranges, got %d", rt.stats)
+	}
+}
+
+// failingRange fails every GetRange with an upstream error and counts Get.
+type failingRange struct {
+	*mock.Backend
+	gets int
+}
+
+func (f *failingRange) Get(ctx context.Context, cid string) (io.ReadCloser, error) {
+	f.gets++
+	return f.Backend.Get(ctx, cid)
+}
+
+func (f *failingRange) GetRange(ctx context.Context, cid string, start, end uint64) (io.ReadCloser, error) {
+	return nil, errors.New("upstream: 503 service unavailable")
+}
+
+func TestGateway_RangeUpstreamErrorDoesNotBuffer(t *testing.T) {
+	rt := &failingRange{Backend: mock.NewBackend()}
+	s := NewServer(rt, nil, DefaultConfig())
+
+	rec := do(s, http.MethodGet, "/ipfs/bafydeadbeef", map[string]string{"Range": "bytes=0-4"})
+	if rec.Code != http.StatusBadGateway {
+		t.Fatalf("expected 502, got %d", rec.Code)
+	}
+	if rt.gets != 0 {
+		t.Fatalf("expected no full-object Get after an upstream error, got %d", rt.gets)
+	}
+}
+
+func TestGateway_NotFound(t *testing.T) {
+	s, _ := newTestServer()
+	rec := do(s, http.MethodGet, "/ipfs/bafymissing", map[string]string{"Range": "bytes=0-9"})
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+}
+
+func TestGateway_DeniedContent(t *testing.T) {
+	s, dl := newTestServer()
+	_ = dl.Add("bafydeadbeef", "copyright")
+
+	rec := do(s, http.MethodGet, "/ipfs/bafydeadbeef", nil)
+	if rec.Code != http.StatusUnavailableForLegalReasons {
+		t.Fatalf("expected 451, got %d", rec.Code)
+	}
+}
+
+func TestGateway_ConditionalAndMethods(t *testing.T) {
+	s, _ := newTestServer()
+
+	rec := do(s, http.MethodGet, "/ipfs/bafydeadbeef", map[string]string{"If-None-Match": `"bafydeadbeef"`})
+	if rec.Code != http.StatusNotModified {
+		t.Fatalf("expected 304, got %d", rec.Code)
+	}
+
+	rec = do(s, http.MethodHead, "/ipfs/bafydeadbeef", map[string]string{"Range": "bytes=0-4"})
+	if rec.Code != http.StatusPartialContent || rec.Body.Len() != 0 {
+		t.Fatalf("expected bodiless 206 for HEAD, got %d (%d bytes)", rec.Code, rec.Body.Len())
+	}
+
+	rec = do(s, http.MethodPost, "/ipfs/bafydeadbeef", nil)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", rec.Code)
+	}
+
+	rec = do(s, http.MethodGet, "/other/bafydeadbeef", nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+}
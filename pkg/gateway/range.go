@@ -0,0 +1,90 @@
+package gateway
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// errUnsatisfiable is returned for a well-formed range that selects no bytes.
+var errUnsatisfiable = errors.New("gateway: range not satisfiable")
+
+// byteRange is a single parsed HTTP byte range. HTTP ranges are inclusive
+// ("bytes=0-499" is 500 bytes); the adapter's are half-open [start, end).
+type byteRange struct {
+	first  uint64 // first byte position (inclusive)
+	last   uint64 // last byte position (inclusive), valid if hasLast
+	suffix uint64 // suffix length for "bytes=-N", valid if isSuffix
+
+	hasLast  bool
+	isSuffix bool
+}
+
+// parseRange parses a Range header. ok is false if the header is absent,
+// malformed, or asks for multiple ranges — callers should then ignore it and
+// serve the full object, as RFC 9110 permits.
+func parseRange(header string) (br byteRange, ok bool, err error) {
+	const prefix = "bytes="
+	if header == "" || !strings.HasPrefix(header, prefix) {
+		return byteRange{}, false, nil
+	}
+	spec := strings.TrimSpace(header[len(prefix):])
+	if strings.Contains(spec, ",") {
+		return byteRange{}, false, nil
+	}
+	dash := strings.IndexByte(spec, '-')
+	if dash < 0 {
+		return byteRange{}, false, nil
+	}
+	startStr, endStr := strings.TrimSpace(spec[:dash]), strings.TrimSpace(spec[dash+1:])
+
+	if startStr == "" {
+		// Suffix range: last N bytes.
+		n, perr := strconv.ParseUint(endStr, 10, 64)
+		if perr != nil {
+			return byteRange{}, false, nil
+		}
+		if n == 0 {
+			return byteRange{}, true, errUnsatisfiable
+		}
+		return byteRange{suffix: n, isSuffix: true}, true, nil
+	}
+
+	first, perr := strconv.ParseUint(startStr, 10, 64)
+	if perr != nil {
+		return byteRange{}, false, nil
+	}
+	br = byteRange{first: first}
+	if endStr != "" {
+		last, perr := strconv.ParseUint(endStr, 10, 64)
+		if perr != nil || last < first {
+			return byteRange{}, false, nil
+		}
+		// A last position of 2^64-1 can't be made exclusive without
+		// overflowing; it means "to the end" anyway.
+		if last != math.MaxUint64 {
+			br.last, br.hasLast = last, true
+		}
+	}
+	return br, true, nil
+}
+
+// resolve converts the range to the adapter's half-open [start, end) for an
+// object of the given size, clamping the end to the object size.
+func (br byteRange) resolve(size uint64) (start, end uint64, err error) {
+	if br.isSuffix {
+		if br.suffix > size {
+			return 0, size, nil
+		}
+		return size - br.suffix, size, nil
+	}
+	if br.first >= size {
+		return 0, 0, errUnsatisfiable
+	}
+	end = size
+	if br.hasLast && br.last < size-1 {
+		end = br.last + 1
+	}
+	return br.first, end, nil
+}
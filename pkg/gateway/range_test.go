@@ -0,0 +1,58 @@
+package gateway
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseRangeAndResolve(t *testing.T) {
+	const size = 1000
+	tests := []struct {
+		header    string
+		ok        bool
+		wantStart uint64
+		wantEnd   uint64 // exclusive
+		wantErr   error
+	}{
+		{"", false, 0, 0, nil},
+		{"bytes=0-499", true, 0, 500, nil},
+		{"bytes=500-999", true, 500, 1000, nil},
+		{"bytes=500-5000", true, 500, 1000, nil}, // clamped to size
+		{"bytes=900-", true, 900, 1000, nil},
+		{"bytes=-100", true, 900, 1000, nil},
+		{"bytes=-5000", true, 0, 1000, nil}, // suffix longer than object
+		{"bytes=1000-", true, 0, 0, errUnsatisfiable},
+		{"bytes=0-0", true, 0, 1, nil},
+		{"bytes=5-3", false, 0, 0, nil},
+		{"bytes=0-1,5-6", false, 0, 0, nil}, // multi-range ignored
+		{"items=0-1", false, 0, 0, nil},
+		{"bytes=abc-", false, 0, 0, nil},
+		{"bytes=0-18446744073709551615", true, 0, 1000, nil},   // max uint64 last
+		{"bytes=10-18446744073709551614", true, 10, 1000, nil}, // last+1 overflow-adjacent
+	}
+	for _, tt := range tests {
+		br, ok, err := parseRange(tt.header)
+		if err != nil {
+			t.Fatalf("%q: unexpected parse error %v", tt.header, err)
+		}
+		if ok != tt.ok {
+			t.Fatalf("%q: ok = %v, want %v", tt.header, ok, tt.ok)
+		}
+		if !ok {
+			continue
+		}
+		start, end, err := br.resolve(size)
+		if !errors.Is(err, tt.wantErr) {
+			t.Fatalf("%q: resolve err = %v, want %v", tt.header, err, tt.wantErr)
+		}
+		if err == nil && (start != tt.wantStart || end != tt.wantEnd) {
+			t.Fatalf("%q: got [%d, %d), want [%d, %d)", tt.header, start, end, tt.wantStart, tt.wantEnd)
+		}
+	}
+}
+
+func TestParseRange_ZeroSuffix(t *testing.T) {
+	if _, _, err := parseRange("bytes=-0"); !errors.Is(err, errUnsatisfiable) {
+		t.Fatalf("expected errUnsatisfiable, got %v", err)
+	}
+}
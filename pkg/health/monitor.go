@@ -0,0 +1,275 @@
+// Package health polls Curio nodes on a schedule and feeds the results into
+// the policy engine.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/quriustus/filstream-curio-adapter/pkg/adapter"
+	"github.com/quriustus/filstream-curio-adapter/pkg/policy"
+)
+
+// Config controls the polling schedule.
+type Config struct {
+	// Interval is the time between polling rounds.
+	Interval time.Duration
+
+	// Jitter is the maximum random offset added to or subtracted from each
+	// Interval so many adapters don't poll nodes in lockstep.
+	Jitter time.Duration
+
+	// Concurrency bounds how many nodes are checked at once.
+	Concurrency int
+
+	// CheckTimeout bounds each health check and proof verification.
+	CheckTimeout time.Duration
+}
+
+// DefaultConfig returns a Config with sensible defaults: poll every 30s
+// (±5s), 8 nodes at a time, 10s per check.
+func DefaultConfig() Config {
+	return Config{
+		Interval:     30 * time.Second,
+		Jitter:       5 * time.Second,
+		Concurrency:  8,
+		CheckTimeout: 10 * time.Second,
+	}
+}
+
+// ProofSource supplies the proof to verify for a node, typically by
+// challenging it for a proof over a sampled CID.
+type ProofSource interface {
+	FetchProof(ctx context.Context, nodeID string) (cid string, proof []byte, err error)
+}
+
+// Monitor periodically checks a registered set of nodes. Each check pushes
+// HealthStatus.Latency into Engine.RecordLatency and syncs GeoLabel via
+// SetGeoLabel; an unhealthy or failed check is recorded with
+// Engine.RecordError so the node's score drops. Nodes whose circuit is due
+// for a half-open probe are probed by their next check, and the result is
+// recorded with RecordProbeResult, so tripped nodes recover without the
+// embedder driving Engine.Probes. When Engine.NeedsProofCheck reports the proof TTL has lapsed,
+// a proof is fetched and verified and the result recorded with
+// RecordProofResult; a failed proof's error is kept in the node's
+// HealthStatus.Message.
+type Monitor struct {
+	checker  adapter.HealthChecker
+	verifier adapter.ProofVerifier
+	proofs   ProofSource
+	engine   *policy.Engine
+	config   Config
+
+	mu     sync.RWMutex
+	nodes  map[string]struct{}
+	status map[string]adapter.HealthStatus
+}
+
+// NewMonitor creates a monitor. verifier and proofs may be nil to disable
+// proof re-verification.
+func NewMonitor(checker adapter.HealthChecker, verifier adapter.ProofVerifier, proofs ProofSource, engine *policy.Engine, cfg Config) *Monitor {
+	if cfg.Concurrency < 1 {
+		cfg.Concurrency = 1
+	}
+	return &Monitor{
+		checker:  checker,
+		verifier: verifier,
+		proofs:   proofs,
+		engine:   engine,
+		config:   cfg,
+		nodes:    make(map[string]struct{}),
+		status:   make(map[string]adapter.HealthStatus),
+	}
+}
+
+// AddNode registers nodeID for polling.
+func (m *Monitor) AddNode(nodeID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nodes[nodeID] = struct{}{}
+}
+
+// RemoveNode stops polling nodeID.
+func (m *Monitor) RemoveNode(nodeID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.nodes, nodeID)
+	delete(m.status, nodeID)
+}
+
+// Nodes returns the registered node IDs in sorted order.
+func (m *Monitor) Nodes() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]string, 0, len(m.nodes))
+	for id := range m.nodes {
+		out = append(out, id)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Status returns the most recent health status for nodeID.
+func (m *Monitor) Status(nodeID string) (adapter.HealthStatus, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	hs, ok := m.status[nodeID]
+	return hs, ok
+}
+
+// Run polls until ctx is cancelled. The first round starts immediately.
+func (m *Monitor) Run(ctx context.Context) error {
+	for {
+		m.CheckAll(ctx)
+
+		timer := time.NewTimer(m.nextDelay())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// CheckAll runs one polling round over every registered node, at most
+// Config.Concurrency at a time, and returns when the round is complete.
+func (m *Monitor) CheckAll(ctx context.Context) {
+	sem := make(chan struct{}, m.config.Concurrency)
+	var wg sync.WaitGroup
+	for _, id := range m.Nodes() {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		}
+		wg.Add(1)
+		go func(nodeID string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			m.checkNode(ctx, nodeID)
+		}(id)
+	}
+	wg.Wait()
+}
+
+// checkNode runs the health check for one node and, if due, proof
+// re-verification. If the node's circuit is due for a probe
+// (Engine.DueForProbe), the check is the probe: it passes if the node is
+// healthy and, with proofs configured, proves its storage.
+func (m *Monitor) checkNode(ctx context.Context, nodeID string) {
+	probe := m.engine.DueForProbe(nodeID)
+
+	cctx, cancel := m.withTimeout(ctx)
+	hs, err := m.checker.CheckHealth(cctx, nodeID)
+	cancel()
+	if err != nil {
+		hs = adapter.HealthStatus{NodeID: nodeID, CheckedAt: time.Now(), Message: err.Error()}
+	}
+
+	m.mu.Lock()
+	if _, ok := m.nodes[nodeID]; ok {
+		m.status[nodeID] = hs
+	}
+	m.mu.Unlock()
+
+	if err != nil || !hs.Healthy {
+		// A cancelled round says nothing about the node.
+		if ctx.Err() == nil {
+			m.engine.RecordError(nodeID, checkErrorKind(err))
+			if probe {
+				m.engine.RecordProbeResult(nodeID, false)
+			}
+		}
+		return
+	}
+	if hs.Latency > 0 {
+		m.engine.RecordLatency(nodeID, hs.Latency)
+	}
+	if hs.GeoLabel != "" {
+		m.engine.SetGeoLabel(nodeID, hs.GeoLabel)
+	}
+
+	passed := true
+	if m.verifier != nil && m.proofs != nil && (probe || m.engine.NeedsProofCheck(nodeID)) {
+		perr := m.verifyProof(ctx, nodeID)
+		if perr != nil {
+			m.setMessage(nodeID, "proof: "+perr.Error())
+		}
+		// A cancelled round or our own timeout says nothing about the
+		// node's proof; leave it, and any probe, due for the next check.
+		if ctx.Err() != nil || errors.Is(perr, context.Canceled) || errors.Is(perr, context.DeadlineExceeded) {
+			return
+		}
+		m.engine.RecordProofResult(nodeID, perr == nil)
+		passed = perr == nil
+	}
+	if probe {
+		m.engine.RecordProbeResult(nodeID, passed)
+	}
+}
+
+// checkErrorKind classifies a failed health check: our own timeout is a
+// timeout, anything else (including an unhealthy report) is unavailable.
+func checkErrorKind(err error) policy.ErrorKind {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return policy.ErrorTimeout
+	}
+	return policy.ErrorUnavailable
+}
+
+// setMessage sets the Message of nodeID's latest status, if it is still
+// registered.
+func (m *Monitor) setMessage(nodeID, msg string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if hs, ok := m.status[nodeID]; ok {
+		hs.Message = msg
+		m.status[nodeID] = hs
+	}
+}
+
+// verifyProof fetches and verifies a proof for nodeID. A nil error means
+// the proof was valid.
+func (m *Monitor) verifyProof(ctx context.Context, nodeID string) error {
+	pctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	cid, proof, err := m.proofs.FetchProof(pctx, nodeID)
+	if err != nil {
+		return fmt.Errorf("fetch proof: %w", err)
+	}
+	ok, err := m.verifier.VerifyProof(pctx, cid, proof)
+	if err != nil {
+		return fmt.Errorf("verify proof: %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("invalid proof for %s", cid)
+	}
+	return nil
+}
+
+func (m *Monitor) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if m.config.CheckTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, m.config.CheckTimeout)
+}
+
+// nextDelay returns Interval offset by a random amount in [-Jitter, +Jitter].
+func (m *Monitor) nextDelay() time.Duration {
+	d := m.config.Interval
+	if m.config.Jitter > 0 {
+		d += time.Duration(rand.Int63n(int64(2*m.config.Jitter)+1)) - m.config.Jitter
+	}
+	if d <= 0 {
+		d = time.Millisecond
+	}
+	return d
+}
@@ -0,0 +1,264 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/quriustus/filstream-curio-adapter/internal/mock"
+	"github.com/quriustus/filstream-curio-adapter/pkg/adapter"
+	"github.com/quriustus/filstream-curio-adapter/pkg/policy"
+)
+
+// staticProofs hands out the same proof for every node and counts requests.
+type staticProofs struct {
+	cid   string
+	proof []byte
+	err   error
+	calls int32
+}
+
+func (s *staticProofs) FetchProof(ctx context.Context, nodeID string) (string, []byte, error) {
+	atomic.AddInt32(&s.calls, 1)
+	return s.cid, s.proof, s.err
+}
+
+func TestMonitor_CheckAllFeedsEngine(t *testing.T) {
+	b := mock.NewBackend()
+	eng := policy.NewEngine(policy.DefaultConfig())
+	proofs := &staticProofs{cid: "bafy1234video", proof: []byte("valid-proof-1234")}
+	m := NewMonitor(b, b, proofs, eng, DefaultConfig())
+	m.AddNode("node-us-east-1")
+	m.AddNode("node-eu-west-1")
+
+	m.CheckAll(context.Background())
+
+	score := eng.Score("node-us-east-1", "")
+	if score.SampleCount != 1 || score.GeoLabel != "us-east" {
+		t.Fatalf("expected latency and geo synced, got %+v", score)
+	}
+	if eng.NeedsProofCheck("node-us-east-1") {
+		t.Fatal("expected proof re-verified")
+	}
+	if proofs.calls != 2 {
+		t.Fatalf("expected 2 proof fetches, got %d", proofs.calls)
+	}
+
+	// Proofs are within TTL now, so a second round does not re-verify.
+	m.CheckAll(context.Background())
+	if proofs.calls != 2 {
+		t.Fatalf("expected no re-verification within TTL, got %d fetches", proofs.calls)
+	}
+	if hs, ok := m.Status("node-eu-west-1"); !ok || !hs.Healthy {
+		t.Fatalf("expected healthy status recorded, got %+v", hs)
+	}
+}
+
+func TestMonitor_FailedProofRecorded(t *testing.T) {
+	b := mock.NewBackend()
+	eng := policy.NewEngine(policy.DefaultConfig())
+	proofs := &staticProofs{err: errors.New("node refused challenge")}
+	m := NewMonitor(b, b, proofs, eng, DefaultConfig())
+	m.AddNode("node-us-east-1")
+
+	m.CheckAll(context.Background())
+
+	if got := eng.Score("node-us-east-1", "").MissedProofs; got != 1 {
+		t.Fatalf("expected 1 missed proof, got %d", got)
+	}
+}
+
+func TestMonitor_ProofErrorInStatus(t *testing.T) {
+	b := mock.NewBackend()
+	eng := policy.NewEngine(policy.DefaultConfig())
+	proofs := &staticProofs{err: errors.New("node refused challenge")}
+	m := NewMonitor(b, b, proofs, eng, DefaultConfig())
+	m.AddNode("node-us-east-1")
+
+	m.CheckAll(context.Background())
+
+	hs, _ := m.Status("node-us-east-1")
+	if !strings.Contains(hs.Message, "node refused challenge") {
+		t.Fatalf("expected proof error in status message, got %q", hs.Message)
+	}
+}
+
+func TestMonitor_ContextErrorNotRecordedAsMissedProof(t *testing.T) {
+	b := mock.NewBackend()
+	eng := policy.NewEngine(policy.DefaultConfig())
+	for _, err := range []error{context.Canceled, context.DeadlineExceeded} {
+		proofs := &staticProofs{err: err}
+		m := NewMonitor(b, b, proofs, eng, DefaultConfig())
+		m.AddNode("node-us-east-1")
+
+		m.CheckAll(context.Background())
+
+		if got := eng.Score("node-us-east-1", "").MissedProofs; got != 0 {
+			t.Fatalf("%v: expected no missed proof, got %d", err, got)
+		}
+		if !eng.NeedsProofCheck("node-us-east-1") {
+			t.Fatalf("%v: expected proof still due", err)
+		}
+	}
+}
+
+func TestMonitor_UnhealthyNodeSkipped(t *testing.T) {
+	b := mock.NewBackend()
+	eng := policy.NewEngine(policy.DefaultConfig())
+	m := NewMonitor(b, nil, nil, eng, DefaultConfig())
+	m.AddNode("node-unknown")
+
+	m.CheckAll(context.Background())
+
+	if got := eng.Score("node-unknown", "").SampleCount; got != 0 {
+		t.Fatalf("expected no samples for unhealthy node, got %d", got)
+	}
+	if hs, ok := m.Status("node-unknown"); !ok || hs.Healthy {
+		t.Fatalf("expected unhealthy status recorded, got %+v", hs)
+	}
+}
+
+// erroringChecker fails every check with err.
+type erroringChecker struct{ err error }
+
+func (c erroringChecker) CheckHealth(ctx context.Context, nodeID string) (adapter.HealthStatus, error) {
+	return adapter.HealthStatus{}, c.err
+}
+
+func TestMonitor_FailedCheckRecordedAsError(t *testing.T) {
+	b := mock.NewBackend()
+	eng := policy.NewEngine(policy.DefaultConfig())
+	m := NewMonitor(b, nil, nil, eng, DefaultConfig())
+	m.AddNode("node-unknown")
+	m.CheckAll(context.Background())
+	if s := eng.Explain("node-unknown", "").Inputs; s.ErrorRate != 1 || s.ErrorsByKind[policy.ErrorUnavailable] != 1 {
+		t.Fatalf("expected unhealthy check recorded as unavailable, got %+v", s)
+	}
+
+	m = NewMonitor(erroringChecker{context.DeadlineExceeded}, nil, nil, eng, DefaultConfig())
+	m.AddNode("node-slow")
+	m.CheckAll(context.Background())
+	if s := eng.Explain("node-slow", "").Inputs; s.ErrorsByKind[policy.ErrorTimeout] != 1 {
+		t.Fatalf("expected timed-out check recorded as timeout, got %+v", s)
+	}
+
+	// A cancelled round records nothing.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	m = NewMonitor(erroringChecker{context.Canceled}, nil, nil, eng, DefaultConfig())
+	m.AddNode("node-cancelled")
+	m.checkNode(ctx, "node-cancelled")
+	if s := eng.Score("node-cancelled", ""); s.ErrorRate != 0 {
+		t.Fatalf("expected cancelled check not recorded, got %+v", s)
+	}
+}
+
+func TestMonitor_ProbesTrippedNodes(t *testing.T) {
+	b := mock.NewBackend()
+	eng := policy.NewEngine(policy.DefaultConfig())
+	now := time.Now()
+	eng.SetClock(func() time.Time { return now })
+	proofs := &staticProofs{cid: "bafy1234video", proof: []byte("valid-proof-1234")}
+	m := NewMonitor(b, b, proofs, eng, DefaultConfig())
+	m.AddNode("node-us-east-1")
+	m.AddNode("node-unknown")
+	for _, id := range []string{"node-us-east-1", "node-unknown"} {
+		for i := 0; i < 3; i++ {
+			eng.RecordIntegrityFailure(id)
+		}
+	}
+
+	// A passing proof alone does not clear integrity failures.
+	m.CheckAll(context.Background())
+	if c := eng.Score("node-us-east-1", "").Circuit; c != policy.CircuitOpen {
+		t.Fatalf("expected circuit open before the probe is due, got %s", c)
+	}
+
+	now = now.Add(eng.Config().HalfOpenProbeInterval)
+	m.CheckAll(context.Background())
+	if s := eng.Score("node-us-east-1", ""); s.Circuit != policy.CircuitClosed || s.IntegrityFailures != 0 {
+		t.Fatalf("expected healthy node closed by its probe, got %+v", s)
+	}
+	if c := eng.Score("node-unknown", "").Circuit; c != policy.CircuitOpen {
+		t.Fatalf("expected unhealthy node re-opened by its probe, got %s", c)
+	}
+	if eng.DueForProbe("node-unknown") {
+		t.Fatal("expected failed probe to restart the probe timer")
+	}
+}
+
+// trackingChecker records the peak number of concurrent checks.
+type trackingChecker struct {
+	mu      sync.Mutex
+	active  int
+	peak    int
+	checked int
+}
+
+func (c *trackingChecker) CheckHealth(ctx context.Context, nodeID string) (adapter.HealthStatus, error) {
+	c.mu.Lock()
+	c.active++
+	c.checked++
+	if c.active > c.peak {
+		c.peak = c.active
+	}
+	c.mu.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+
+	c.mu.Lock()
+	c.active--
+	c.mu.Unlock()
+	return adapter.HealthStatus{NodeID: nodeID, Healthy: true, Latency: time.Millisecond}, nil
+}
+
+func TestMonitor_BoundedConcurrency(t *testing.T) {
+	checker := &trackingChecker{}
+	cfg := DefaultConfig()
+	cfg.Concurrency = 2
+	m := NewMonitor(checker, nil, nil, policy.NewEngine(policy.DefaultConfig()), cfg)
+	for _, id := range []string{"a", "b", "c", "d", "e", "f"} {
+		m.AddNode(id)
+	}
+
+	m.CheckAll(context.Background())
+
+	if checker.checked != 6 {
+		t.Fatalf("expected 6 checks, got %d", checker.checked)
+	}
+	if checker.peak > 2 {
+		t.Fatalf("expected at most 2 concurrent checks, saw %d", checker.peak)
+	}
+}
+
+func TestMonitor_RunStopsOnCancel(t *testing.T) {
+	checker := &trackingChecker{}
+	cfg := Config{Interval: 5 * time.Millisecond, Jitter: 2 * time.Millisecond, Concurrency: 1}
+	m := NewMonitor(checker, nil, nil, policy.NewEngine(policy.DefaultConfig()), cfg)
+	m.AddNode("a")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Millisecond)
+	defer cancel()
+	if err := m.Run(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected DeadlineExceeded, got %v", err)
+	}
+	checker.mu.Lock()
+	defer checker.mu.Unlock()
+	if checker.checked < 2 {
+		t.Fatalf("expected several polling rounds, got %d", checker.checked)
+	}
+}
+
+func TestMonitor_NextDelayWithinJitter(t *testing.T) {
+	m := NewMonitor(nil, nil, nil, nil, Config{Interval: time.Second, Jitter: 100 * time.Millisecond})
+	for i := 0; i < 100; i++ {
+		d := m.nextDelay()
+		if d < 900*time.Millisecond || d > 1100*time.Millisecond {
+			t.Fatalf("delay %v outside jitter bounds", d)
+		}
+	}
+}
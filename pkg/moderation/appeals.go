@@ -0,0 +1,282 @@
+package moderation
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// AppealReviewBusinessDays is how long moderators have to decide an
+// appeal.
+const AppealReviewBusinessDays = 5
+
+var (
+	// ErrDecisionNotFound is returned when the appealed audit record
+	// doesn't exist.
+	ErrDecisionNotFound = errors.New("moderation: decision not found")
+
+	// ErrNotAppealable is returned for decisions that can't be appealed:
+	// anything but a deny, and any CSAM outcome.
+	ErrNotAppealable = errors.New("moderation: decision not appealable")
+
+	// ErrAlreadyAppealed is returned for a second appeal of a decision.
+	ErrAlreadyAppealed = errors.New("moderation: decision already appealed")
+
+	// ErrAppealNotFound is returned for an unknown appeal ID.
+	ErrAppealNotFound = errors.New("moderation: appeal not found")
+
+	// ErrAppealClosed is returned when reviewing an appeal already decided.
+	ErrAppealClosed = errors.New("moderation: appeal already decided")
+
+	// ErrSameReviewer is returned when the moderator who made a decision
+	// tries to review its appeal.
+	ErrSameReviewer = errors.New("moderation: appeal reviewer made the original decision")
+
+	// ErrNoReviewer is returned when a review names no reviewer.
+	ErrNoReviewer = errors.New("moderation: reviewer required")
+)
+
+// AppealStatus is where an appeal is in review.
+type AppealStatus string
+
+const (
+	AppealPending  AppealStatus = "pending"
+	AppealUpheld   AppealStatus = "upheld"   // original decision stands
+	AppealReversed AppealStatus = "reversed" // content restored, strike withdrawn
+)
+
+// Appeal is a request to reconsider a moderation decision. Decision is a
+// copy of the audit record being appealed.
+type Appeal struct {
+	ID         string       `json:"id"`
+	Decision   AuditRecord  `json:"decision"`
+	Appellant  string       `json:"appellant"`
+	Statement  string       `json:"statement"`
+	FiledAt    time.Time    `json:"filed_at"`
+	DueBy      time.Time    `json:"due_by"`
+	Status     AppealStatus `json:"status"`
+	ReviewedBy string       `json:"reviewed_by,omitempty"`
+	ReviewedAt time.Time    `json:"reviewed_at,omitempty"`
+	Notes      string       `json:"notes,omitempty"`
+}
+
+// AppealService is the in-memory AppealQueue. Decisions are looked up in
+// the AuditLog by record ID; each can be appealed once, must be reviewed
+// by a different moderator than its ActionBy, and is due within
+// AppealReviewBusinessDays. CSAM outcomes, identified by the decision's
+// Category, can't be appealed. A reversal releases the decision's
+// DenyList hold, leaving content denied for other reasons down, and, with
+// a StrikeLedger set, withdraws the strike issued for the decision's
+// flag. Filing and review are written to the
+// AuditLog with the decision's FlagID.
+type AppealService struct {
+	deny  DenyList
+	audit AuditLog
+
+	mu         sync.Mutex
+	appeals    map[string]*Appeal
+	byDecision map[string]string // audit record ID → appeal ID
+	seq        int
+	now        func() time.Time
+	cal        Calendar
+	strikes    StrikeLedger
+}
+
+// NewAppealService creates a service reading decisions from audit and
+// restoring reversed content on deny.
+func NewAppealService(deny DenyList, audit AuditLog) *AppealService {
+	return &AppealService{
+		deny:       deny,
+		audit:      audit,
+		appeals:    make(map[string]*Appeal),
+		byDecision: make(map[string]string),
+		now:        time.Now,
+		cal:        DefaultBusinessCalendar(),
+	}
+}
+
+// SetClock replaces the service's time source. Intended for tests.
+func (s *AppealService) SetClock(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
+// SetCalendar replaces the business-day calendar used for review
+// deadlines (DefaultBusinessCalendar by default).
+func (s *AppealService) SetCalendar(cal Calendar) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cal = cal
+}
+
+// SetStrikeLedger enables strike reversal for reversed appeals.
+func (s *AppealService) SetStrikeLedger(l StrikeLedger) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.strikes = l
+}
+
+// File opens an appeal against the audit record decisionID.
+func (s *AppealService) File(decisionID, appellant, statement string) (Appeal, error) {
+	decision, err := s.decision(decisionID)
+	if err != nil {
+		return Appeal{}, err
+	}
+	if decision.Action != ActionDeny {
+		return Appeal{}, fmt.Errorf("%w: %s is %s", ErrNotAppealable, decisionID, decision.Action)
+	}
+	if decision.Category == CategoryCSAM {
+		return Appeal{}, fmt.Errorf("%w: %s is a CSAM outcome", ErrNotAppealable, decisionID)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if id, dup := s.byDecision[decisionID]; dup {
+		return Appeal{}, fmt.Errorf("%w: %s (appeal %s)", ErrAlreadyAppealed, decisionID, id)
+	}
+	now := s.now()
+	s.seq++
+	a := &Appeal{
+		ID:        fmt.Sprintf("appeal-%d", s.seq),
+		Decision:  decision,
+		Appellant: appellant,
+		Statement: statement,
+		FiledAt:   now,
+		DueBy:     s.cal.AddBusinessDays(now, AppealReviewBusinessDays),
+		Status:    AppealPending,
+	}
+	s.appeals[a.ID] = a
+	s.byDecision[decisionID] = a.ID
+	s.record(a, ActionAppealFiled, appellant, statement, now)
+	return *a, nil
+}
+
+// Review decides a pending appeal: AppealUpheld keeps the decision,
+// AppealReversed releases the decision's hold on the content and withdraws
+// the strike. reviewedBy must be set and differ from the decision's
+// ActionBy.
+func (s *AppealService) Review(appealID string, outcome AppealStatus, reviewedBy, notes string) error {
+	if outcome != AppealUpheld && outcome != AppealReversed {
+		return fmt.Errorf("moderation: invalid appeal outcome %q", outcome)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.appeals[appealID]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrAppealNotFound, appealID)
+	}
+	if a.Status != AppealPending {
+		return fmt.Errorf("%w: %s is %s", ErrAppealClosed, appealID, a.Status)
+	}
+	if reviewedBy == "" {
+		return fmt.Errorf("%w: appeal %s", ErrNoReviewer, appealID)
+	}
+	if reviewedBy == a.Decision.ActionBy {
+		return fmt.Errorf("%w: %s", ErrSameReviewer, reviewedBy)
+	}
+
+	if outcome == AppealReversed {
+		if err := ReleaseHold(s.deny, a.Decision.ContentID, flagHold(a.Decision.Category, a.Decision.FlagID)); err != nil {
+			return fmt.Errorf("moderation: restore %s: %w", a.Decision.ContentID, err)
+		}
+		if s.strikes != nil {
+			if err := s.strikes.ReverseStrike(a.Decision.FlagID, "appeal "+a.ID+" reversed"); err != nil && !errors.Is(err, ErrStrikeNotFound) {
+				return fmt.Errorf("moderation: reverse strike %s: %w", a.Decision.FlagID, err)
+			}
+		}
+	}
+
+	now := s.now()
+	a.Status = outcome
+	a.ReviewedBy = reviewedBy
+	a.ReviewedAt = now
+	a.Notes = notes
+	action := ActionAppealUpheld
+	if outcome == AppealReversed {
+		action = ActionAppealReversed
+	}
+	s.record(a, action, reviewedBy, notes, now)
+	return nil
+}
+
+// Get returns a copy of the appeal.
+func (s *AppealService) Get(appealID string) (Appeal, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.appeals[appealID]
+	if !ok {
+		return Appeal{}, false
+	}
+	return *a, true
+}
+
+// Pending returns undecided appeals, oldest deadline first.
+func (s *AppealService) Pending() ([]Appeal, error) {
+	return s.pending(time.Time{}), nil
+}
+
+// Overdue returns undecided appeals past their DueBy, oldest first.
+func (s *AppealService) Overdue() []Appeal {
+	s.mu.Lock()
+	now := s.now()
+	s.mu.Unlock()
+	return s.pending(now)
+}
+
+// pending returns undecided appeals due before cutoff (all if zero).
+func (s *AppealService) pending(cutoff time.Time) []Appeal {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Appeal
+	for _, a := range s.appeals {
+		if a.Status == AppealPending && (cutoff.IsZero() || cutoff.After(a.DueBy)) {
+			out = append(out, *a)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].DueBy.Equal(out[j].DueBy) {
+			return out[i].DueBy.Before(out[j].DueBy)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// decision finds the audit record with ID id.
+func (s *AppealService) decision(id string) (AuditRecord, error) {
+	if s.audit == nil {
+		return AuditRecord{}, fmt.Errorf("%w: %s", ErrDecisionNotFound, id)
+	}
+	records, err := s.audit.GetAll()
+	if err != nil {
+		return AuditRecord{}, fmt.Errorf("moderation: read audit log: %w", err)
+	}
+	for _, r := range records {
+		if r.ID == id {
+			return r, nil
+		}
+	}
+	return AuditRecord{}, fmt.Errorf("%w: %s", ErrDecisionNotFound, id)
+}
+
+// record appends an audit record for an appeal transition. Caller must
+// hold s.mu.
+func (s *AppealService) record(a *Appeal, action ReviewAction, by, reason string, at time.Time) {
+	_ = s.audit.Append(AuditRecord{
+		ID:        fmt.Sprintf("audit-%s-%s", a.ID, action),
+		FlagID:    a.Decision.FlagID,
+		ContentID: a.Decision.ContentID,
+		Action:    action,
+		ActionBy:  by,
+		Reason:    reason,
+		Timestamp: at,
+	})
+}
+
+var _ AppealQueue = (*AppealService)(nil)
@@ -0,0 +1,189 @@
+package moderation
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// moderationFixture wires a moderation queue and appeal service to one
+// deny list, audit log, strike registry and CSAM reporter.
+type moderationFixture struct {
+	queue    *MockModerationQueue
+	appeals  *AppealService
+	deny     *MockDenyList
+	audit    *MockAuditLog
+	strikes  *UploaderRegistry
+	reporter *MockCSAMReporter
+	clock    *testClock
+}
+
+func newModerationFixture() *moderationFixture {
+	f := &moderationFixture{
+		deny:     NewMockDenyList(),
+		audit:    NewMockAuditLog(),
+		reporter: NewMockCSAMReporter(),
+		clock:    &testClock{t: time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)},
+	}
+	f.strikes = NewUploaderRegistry(DefaultStrikePolicy(), nil)
+	f.strikes.SetClock(f.clock.Now)
+	f.queue = NewMockModerationQueue(f.deny, f.audit, DefaultEscalationConfig())
+	f.queue.SetStrikeLedger(f.strikes)
+	f.queue.SetCSAMReporter(f.reporter)
+	f.appeals = NewAppealService(f.deny, f.audit)
+	f.appeals.SetClock(f.clock.Now)
+	f.appeals.SetStrikeLedger(f.strikes)
+	return f
+}
+
+// decide flags and denies content, returning the decision's audit record ID.
+func (f *moderationFixture) decide(flagID string, category FlagCategory) string {
+	f.queue.Submit(ContentFlag{ID: flagID, ContentID: "vid-" + flagID, Uploader: "alice", Category: category})
+	f.queue.Review(flagID, ActionDeny, "mod-1")
+	return "audit-" + flagID
+}
+
+func TestAppealService_ReversalRestoresAndWithdrawsStrike(t *testing.T) {
+	f := newModerationFixture()
+	decision := f.decide("f1", CategoryAbuse)
+
+	a, err := f.appeals.File(decision, "alice", "satire, not abuse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Filed Mon 2 Mar 2026: 5 business days is Mon 9 Mar.
+	if want := time.Date(2026, 3, 9, 9, 0, 0, 0, time.UTC); !a.DueBy.Equal(want) || a.Decision.ContentID != "vid-f1" {
+		t.Fatalf("unexpected appeal %+v", a)
+	}
+	if err := f.appeals.Review(a.ID, AppealReversed, "mod-2", "context shows satire"); err != nil {
+		t.Fatal(err)
+	}
+
+	if denied, _ := f.deny.IsDenied("vid-f1"); denied {
+		t.Fatal("expected content restored")
+	}
+	if st := f.strikes.Status("alice"); st.ActiveStrikes != 0 {
+		t.Fatalf("expected strike withdrawn, got %+v", st)
+	}
+	got, _ := f.appeals.Get(a.ID)
+	if got.Status != AppealReversed || got.ReviewedBy != "mod-2" {
+		t.Fatalf("unexpected appeal after review %+v", got)
+	}
+	records, _ := f.audit.GetByFlag("f1")
+	want := []ReviewAction{ActionDeny, ActionAppealFiled, ActionAppealReversed}
+	if len(records) != len(want) {
+		t.Fatalf("expected %d audit records, got %+v", len(want), records)
+	}
+	for i, r := range records {
+		if r.Action != want[i] {
+			t.Fatalf("record %d: expected %s, got %s", i, want[i], r.Action)
+		}
+	}
+}
+
+func TestAppealService_Rules(t *testing.T) {
+	f := newModerationFixture()
+	decision := f.decide("f1", CategoryAbuse)
+	csam := f.decide("f2", CategoryCSAM)
+	f.queue.Submit(ContentFlag{ID: "f3", ContentID: "vid-f3", Category: CategoryAbuse})
+	f.queue.Review("f3", ActionDismiss, "mod-1")
+
+	a, _ := f.appeals.File(decision, "alice", "")
+	tests := []struct {
+		name string
+		err  error
+		call func() error
+	}{
+		{"second appeal", ErrAlreadyAppealed, func() error { _, err := f.appeals.File(decision, "alice", ""); return err }},
+		{"csam outcome", ErrNotAppealable, func() error { _, err := f.appeals.File(csam, "alice", ""); return err }},
+		{"not a deny", ErrNotAppealable, func() error { _, err := f.appeals.File("audit-f3", "alice", ""); return err }},
+		{"unknown decision", ErrDecisionNotFound, func() error { _, err := f.appeals.File("audit-nope", "alice", ""); return err }},
+		{"no reviewer", ErrNoReviewer, func() error { return f.appeals.Review(a.ID, AppealReversed, "", "") }},
+		{"original moderator", ErrSameReviewer, func() error { return f.appeals.Review(a.ID, AppealReversed, "mod-1", "") }},
+		{"unknown appeal", ErrAppealNotFound, func() error { return f.appeals.Review("appeal-99", AppealUpheld, "mod-2", "") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); !errors.Is(err, tt.err) {
+				t.Fatalf("expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+
+	if err := f.appeals.Review(a.ID, AppealUpheld, "mod-2", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.appeals.Review(a.ID, AppealReversed, "mod-3", ""); !errors.Is(err, ErrAppealClosed) {
+		t.Fatalf("expected ErrAppealClosed, got %v", err)
+	}
+	if denied, _ := f.deny.IsDenied("vid-f1"); !denied {
+		t.Fatal("expected upheld decision to keep content denied")
+	}
+}
+
+func TestAppealService_Overdue(t *testing.T) {
+	f := newModerationFixture()
+	a, _ := f.appeals.File(f.decide("f1", CategoryAbuse), "alice", "")
+	f.appeals.File(f.decide("f2", CategoryAbuse), "alice", "")
+
+	// Friday: 4 business days in, nothing overdue yet.
+	f.clock.Advance(4 * 24 * time.Hour)
+	if got := f.appeals.Overdue(); len(got) != 0 {
+		t.Fatalf("expected nothing overdue, got %+v", got)
+	}
+	f.appeals.Review(a.ID, AppealUpheld, "mod-2", "")
+
+	f.clock.Advance(4 * 24 * time.Hour)
+	if got := f.appeals.Overdue(); len(got) != 1 || got[0].Decision.FlagID != "f2" {
+		t.Fatalf("expected only the undecided appeal overdue, got %+v", got)
+	}
+	if pending, _ := f.appeals.Pending(); len(pending) != 1 {
+		t.Fatalf("expected one pending appeal, got %+v", pending)
+	}
+}
+
+func TestAppealService_ReversalKeepsOtherHolds(t *testing.T) {
+	tests := []struct {
+		name string
+		hold func(f *moderationFixture)
+	}{
+		{"dmca takedown", func(f *moderationFixture) {
+			s := NewDMCAService(f.deny, nil)
+			s.SubmitNotice(validNotice("vid-f1"))
+		}},
+		{"csam escalation", func(f *moderationFixture) {
+			f.queue.Submit(ContentFlag{ID: "f9", ContentID: "vid-f1", Category: CategoryCSAM})
+		}},
+		{"other deny decision", func(f *moderationFixture) {
+			f.queue.Submit(ContentFlag{ID: "f9", ContentID: "vid-f1", Category: CategoryCopyright})
+			f.queue.Review("f9", ActionDeny, "mod-1")
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newModerationFixture()
+			a, _ := f.appeals.File(f.decide("f1", CategoryAbuse), "alice", "")
+			tt.hold(f)
+
+			if err := f.appeals.Review(a.ID, AppealReversed, "mod-2", ""); err != nil {
+				t.Fatal(err)
+			}
+			if denied, _ := f.deny.IsDenied("vid-f1"); !denied {
+				t.Fatal("expected content still denied by the other hold")
+			}
+		})
+	}
+}
+
+func TestAppealService_CSAMByCategory(t *testing.T) {
+	f := newModerationFixture()
+	f.audit.Append(AuditRecord{ID: "audit-a", FlagID: "a", ContentID: "vid-a", Category: CategoryCSAM, Action: ActionDeny, ActionBy: "mod-1", Reason: "confirmed by reviewer"})
+	f.audit.Append(AuditRecord{ID: "audit-b", FlagID: "b", ContentID: "vid-b", Category: CategoryAbuse, Action: ActionDeny, ActionBy: "mod-1", Reason: "csam"})
+
+	if _, err := f.appeals.File("audit-a", "alice", ""); !errors.Is(err, ErrNotAppealable) {
+		t.Fatalf("expected CSAM category to be unappealable, got %v", err)
+	}
+	if _, err := f.appeals.File("audit-b", "alice", ""); err != nil {
+		t.Fatalf("expected non-CSAM category appealable whatever its reason, got %v", err)
+	}
+}
@@ -0,0 +1,181 @@
+package moderation
+
+import (
+	"sync"
+	"time"
+)
+
+// DMCA counter-notice restore window, in business days after receipt
+// (17 U.S.C. § 512(g)(2)(C)).
+const (
+	DMCARestoreMinBusinessDays = 10
+	DMCARestoreMaxBusinessDays = 14
+)
+
+// Calendar computes business-day deadlines. DMCAService and review SLA
+// timers share one so they agree on what a business day is.
+type Calendar interface {
+	// IsBusinessDay reports whether t's date is a business day.
+	IsBusinessDay(t time.Time) bool
+
+	// AddBusinessDays returns the time n business days after t, at the
+	// same wall-clock time. t's own date is never counted.
+	AddBusinessDays(t time.Time, n int) time.Time
+}
+
+// Holiday is a recurring non-business day.
+type Holiday struct {
+	Name string
+
+	// Date returns the holiday's date in year (time of day ignored).
+	Date func(year int) time.Time
+
+	// Observed moves a holiday falling on Saturday to the Friday before
+	// and one falling on Sunday to the Monday after, as federal holidays
+	// are observed.
+	Observed bool
+
+	// FromYear is the first year the holiday applies; zero means always.
+	FromYear int
+}
+
+// FixedHoliday is a holiday on the same date every year, observed on the
+// nearest weekday when it falls on a weekend.
+func FixedHoliday(name string, month time.Month, day int) Holiday {
+	return Holiday{
+		Name:     name,
+		Date:     func(year int) time.Time { return time.Date(year, month, day, 0, 0, 0, 0, time.UTC) },
+		Observed: true,
+	}
+}
+
+// NthWeekdayHoliday is a holiday on the nth weekday of month, e.g. the
+// third Monday of January. n = -1 means the last such weekday.
+func NthWeekdayHoliday(name string, month time.Month, weekday time.Weekday, n int) Holiday {
+	return Holiday{
+		Name: name,
+		Date: func(year int) time.Time {
+			if n < 0 {
+				d := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC) // last day of month
+				return d.AddDate(0, 0, -((int(d.Weekday()) - int(weekday) + 7) % 7))
+			}
+			d := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+			d = d.AddDate(0, 0, (int(weekday)-int(d.Weekday())+7)%7)
+			return d.AddDate(0, 0, 7*(n-1))
+		},
+	}
+}
+
+// USFederalHolidays returns the eleven US federal holidays (5 U.S.C.
+// § 6103), with Juneteenth from 2021.
+func USFederalHolidays() []Holiday {
+	juneteenth := FixedHoliday("Juneteenth National Independence Day", time.June, 19)
+	juneteenth.FromYear = 2021
+	return []Holiday{
+		FixedHoliday("New Year's Day", time.January, 1),
+		NthWeekdayHoliday("Birthday of Martin Luther King, Jr.", time.January, time.Monday, 3),
+		NthWeekdayHoliday("Washington's Birthday", time.February, time.Monday, 3),
+		NthWeekdayHoliday("Memorial Day", time.May, time.Monday, -1),
+		juneteenth,
+		FixedHoliday("Independence Day", time.July, 4),
+		NthWeekdayHoliday("Labor Day", time.September, time.Monday, 1),
+		NthWeekdayHoliday("Columbus Day", time.October, time.Monday, 2),
+		FixedHoliday("Veterans Day", time.November, 11),
+		NthWeekdayHoliday("Thanksgiving Day", time.November, time.Thursday, 4),
+		FixedHoliday("Christmas Day", time.December, 25),
+	}
+}
+
+// BusinessCalendar is a Calendar with Saturday and Sunday off plus a set
+// of holidays, evaluated in a fixed time zone.
+type BusinessCalendar struct {
+	loc      *time.Location
+	holidays []Holiday
+
+	mu     sync.Mutex
+	byYear map[int]map[civilDate]bool // observed holiday dates, by year of the date
+}
+
+type civilDate struct {
+	year  int
+	month time.Month
+	day   int
+}
+
+// NewBusinessCalendar creates a calendar in loc (UTC if nil) with the
+// given holidays.
+func NewBusinessCalendar(loc *time.Location, holidays ...Holiday) *BusinessCalendar {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return &BusinessCalendar{
+		loc:      loc,
+		holidays: append([]Holiday(nil), holidays...),
+		byYear:   make(map[int]map[civilDate]bool),
+	}
+}
+
+// DefaultBusinessCalendar returns a UTC calendar with US federal holidays.
+func DefaultBusinessCalendar() *BusinessCalendar {
+	return NewBusinessCalendar(time.UTC, USFederalHolidays()...)
+}
+
+// IsBusinessDay reports whether t's date, in the calendar's zone, is a
+// weekday and not an (observed) holiday.
+func (c *BusinessCalendar) IsBusinessDay(t time.Time) bool {
+	t = t.In(c.loc)
+	if wd := t.Weekday(); wd == time.Saturday || wd == time.Sunday {
+		return false
+	}
+	y, m, d := t.Date()
+	return !c.holidaysIn(y)[civilDate{y, m, d}]
+}
+
+// AddBusinessDays returns the time n business days after t, at the same
+// wall-clock time in the calendar's zone. n <= 0 returns t.
+func (c *BusinessCalendar) AddBusinessDays(t time.Time, n int) time.Time {
+	t = t.In(c.loc)
+	for n > 0 {
+		t = t.AddDate(0, 0, 1)
+		if c.IsBusinessDay(t) {
+			n--
+		}
+	}
+	return t
+}
+
+// holidaysIn returns the observed holiday dates falling in year. A
+// holiday can be observed in the year before its own (New Year's Day on a
+// Saturday is observed on December 31), so the next year's holidays are
+// considered too.
+func (c *BusinessCalendar) holidaysIn(year int) map[civilDate]bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if set, ok := c.byYear[year]; ok {
+		return set
+	}
+	set := make(map[civilDate]bool)
+	for _, hy := range []int{year, year + 1} {
+		for _, h := range c.holidays {
+			if h.FromYear != 0 && hy < h.FromYear {
+				continue
+			}
+			d := h.Date(hy)
+			if h.Observed {
+				switch d.Weekday() {
+				case time.Saturday:
+					d = d.AddDate(0, 0, -1)
+				case time.Sunday:
+					d = d.AddDate(0, 0, 1)
+				}
+			}
+			if dy, dm, dd := d.Date(); dy == year {
+				set[civilDate{dy, dm, dd}] = true
+			}
+		}
+	}
+	c.byYear[year] = set
+	return set
+}
+
+var _ Calendar = (*BusinessCalendar)(nil)
@@ -0,0 +1,101 @@
+package moderation
+
+import (
+	"testing"
+	"time"
+)
+
+func day(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 9, 30, 0, 0, time.UTC)
+}
+
+func TestBusinessCalendar_IsBusinessDay(t *testing.T) {
+	cal := DefaultBusinessCalendar()
+	tests := []struct {
+		name string
+		date time.Time
+		want bool
+	}{
+		{"plain weekday", day(2026, 3, 4), true},
+		{"saturday", day(2026, 3, 7), false},
+		{"sunday", day(2026, 3, 8), false},
+		{"new year's day", day(2026, 1, 1), false},
+		{"mlk day, third monday", day(2026, 1, 19), false},
+		{"monday before mlk day", day(2026, 1, 12), true},
+		{"presidents day", day(2026, 2, 16), false},
+		{"memorial day, last monday", day(2026, 5, 25), false},
+		{"juneteenth", day(2026, 6, 19), false},
+		{"juneteenth before 2021", day(2020, 6, 19), true},
+		{"independence day on saturday, observed friday", day(2026, 7, 3), false},
+		{"labor day", day(2026, 9, 7), false},
+		{"columbus day", day(2026, 10, 12), false},
+		{"veterans day", day(2026, 11, 11), false},
+		{"thanksgiving", day(2026, 11, 26), false},
+		{"day after thanksgiving", day(2026, 11, 27), true},
+		{"christmas", day(2026, 12, 25), false},
+		{"christmas on sunday, observed monday", day(2022, 12, 26), false},
+		{"new year 2022 on saturday, observed dec 31 2021", day(2021, 12, 31), false},
+		{"new year 2028 on saturday, observed dec 31 2027", day(2027, 12, 31), false},
+		{"dec 30 2027", day(2027, 12, 30), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cal.IsBusinessDay(tt.date); got != tt.want {
+				t.Fatalf("IsBusinessDay(%s) = %v, want %v", tt.date.Format("Mon 2006-01-02"), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBusinessCalendar_AddBusinessDays(t *testing.T) {
+	cal := DefaultBusinessCalendar()
+	tests := []struct {
+		name string
+		from time.Time
+		n    int
+		want time.Time
+	}{
+		{"zero days", day(2026, 3, 4), 0, day(2026, 3, 4)},
+		{"two plain weeks", day(2026, 3, 2), 10, day(2026, 3, 16)},
+		{"from saturday", day(2026, 3, 7), 1, day(2026, 3, 9)},
+		{"over observed independence day", day(2026, 7, 2), 1, day(2026, 7, 6)},
+		{"over thanksgiving", day(2026, 11, 20), 10, day(2026, 12, 7)},
+		{"into next year", day(2026, 12, 22), 10, day(2027, 1, 7)},
+		{"over two observed holidays at year end", day(2027, 12, 23), 5, day(2028, 1, 3)},
+		{"14 days over christmas and mlk", day(2026, 12, 31), 14, day(2027, 1, 22)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cal.AddBusinessDays(tt.from, tt.n); !got.Equal(tt.want) {
+				t.Fatalf("AddBusinessDays(%s, %d) = %s, want %s", tt.from.Format("Mon 2006-01-02"), tt.n,
+					got.Format("Mon 2006-01-02 15:04"), tt.want.Format("Mon 2006-01-02 15:04"))
+			}
+		})
+	}
+}
+
+func TestBusinessCalendar_Configurable(t *testing.T) {
+	weekendsOnly := NewBusinessCalendar(nil)
+	if !weekendsOnly.IsBusinessDay(day(2026, 12, 25)) {
+		t.Fatal("expected no holidays without a holiday list")
+	}
+
+	company := NewBusinessCalendar(time.UTC, append(USFederalHolidays(),
+		NthWeekdayHoliday("Day after Thanksgiving", time.November, time.Friday, 4))...)
+	if company.IsBusinessDay(day(2026, 11, 27)) {
+		t.Fatal("expected custom holiday honoured")
+	}
+}
+
+func TestBusinessCalendar_Location(t *testing.T) {
+	// 03:00 UTC on Tuesday 20 Jan 2026 is still MLK day in UTC-5, so the
+	// zone decides which date is checked.
+	east := NewBusinessCalendar(time.FixedZone("EST", -5*3600), USFederalHolidays()...)
+	at := time.Date(2026, 1, 20, 3, 0, 0, 0, time.UTC) // Mon 19 Jan 22:00 EST
+	if east.IsBusinessDay(at) {
+		t.Fatal("expected MLK day in EST")
+	}
+	if !DefaultBusinessCalendar().IsBusinessDay(at) {
+		t.Fatal("expected Tuesday in UTC to be a business day")
+	}
+}
@@ -0,0 +1,149 @@
+package moderation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrNoCSAMReporter is returned for a CSAM flag submitted to a queue with
+// no CSAMReporter set; the other fast-path measures are still applied.
+var ErrNoCSAMReporter = errors.New("moderation: no CSAM reporter configured")
+
+// ErrCSAMSameReviewer is returned when a CSAM flag is approved or dismissed
+// by whoever raised it. Clearing one also needs a named reviewer
+// (ErrNoReviewer).
+var ErrCSAMSameReviewer = errors.New("moderation: CSAM flag cleared by the account that raised it")
+
+// CSAMPreservationPeriod is how long reported content and its metadata
+// must be preserved after a CyberTipline report (18 U.S.C. § 2258A(h)).
+const CSAMPreservationPeriod = 365 * 24 * time.Hour
+
+// CyberTipReport is the payload for an NCMEC CyberTipline report of
+// apparent CSAM, shaped after the CyberTipline reporting API's incident,
+// reported-person and file sections.
+//
+// Evidence handling: the report identifies content by ID only. Content
+// bytes are never read, copied into the report, or written to the audit
+// log; the flag's free-text evidence goes into the report (which
+// FileReportQueue writes with owner-only permissions) but never into
+// AuditRecord.Reason.
+type CyberTipReport struct {
+	ID             string            `json:"id"`
+	IncidentType   string            `json:"incident_type"`
+	IncidentTime   time.Time         `json:"incident_time"`
+	CreatedAt      time.Time         `json:"created_at"`
+	Reporter       CyberTipReporter  `json:"reporter"`
+	ReportedPerson CyberTipPerson    `json:"reported_person,omitempty"`
+	Files          []CyberTipFile    `json:"files"`
+	Flag           CyberTipFlagInfo  `json:"flag"`
+	Preservation   CyberTipRetention `json:"preservation"`
+}
+
+// CyberTipReporter identifies the reporting service provider.
+type CyberTipReporter struct {
+	ESPName      string `json:"esp_name,omitempty"`
+	ContactName  string `json:"contact_name,omitempty"`
+	ContactEmail string `json:"contact_email,omitempty"`
+}
+
+// CyberTipPerson is the uploader of the reported content.
+type CyberTipPerson struct {
+	UploaderID string `json:"uploader_id,omitempty"`
+}
+
+// CyberTipFile references one reported file without its contents.
+type CyberTipFile struct {
+	ContentID         string `json:"content_id"`
+	ViewedByESP       bool   `json:"viewed_by_esp"`
+	PubliclyAvailable bool   `json:"publicly_available"`
+}
+
+// CyberTipFlagInfo is how the content came to the service's attention.
+type CyberTipFlagInfo struct {
+	FlagID    string    `json:"flag_id"`
+	FlaggedBy string    `json:"flagged_by"`
+	FlaggedAt time.Time `json:"flagged_at"`
+	Evidence  string    `json:"evidence,omitempty"`
+}
+
+// CyberTipRetention records the preservation obligation for the report.
+type CyberTipRetention struct {
+	PreserveUntil time.Time `json:"preserve_until"`
+}
+
+// NewCyberTipReport builds the report for a CSAM flag. The content was
+// served publicly and has not been viewed by staff at flag time.
+func NewCyberTipReport(flag ContentFlag, at time.Time) CyberTipReport {
+	return CyberTipReport{
+		ID:             "cybertip-" + flag.ID,
+		IncidentType:   "child_pornography",
+		IncidentTime:   flag.Timestamp,
+		CreatedAt:      at,
+		ReportedPerson: CyberTipPerson{UploaderID: flag.Uploader},
+		Files:          []CyberTipFile{{ContentID: flag.ContentID, PubliclyAvailable: true}},
+		Flag: CyberTipFlagInfo{
+			FlagID:    flag.ID,
+			FlaggedBy: flag.FlaggedBy,
+			FlaggedAt: flag.Timestamp,
+			Evidence:  flag.Evidence,
+		},
+		Preservation: CyberTipRetention{PreserveUntil: at.Add(CSAMPreservationPeriod)},
+	}
+}
+
+// FileReportQueue is a CSAMReporter that spools each report as a JSON
+// file in a directory, for a separate submitter to send to NCMEC. Files
+// are written atomically with owner-only permissions.
+type FileReportQueue struct {
+	dir string
+
+	// Reporter is stamped into reports that don't name one.
+	Reporter CyberTipReporter
+}
+
+// NewFileReportQueue creates a queue spooling to dir, creating it if
+// needed.
+func NewFileReportQueue(dir string) (*FileReportQueue, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("moderation: create report queue: %w", err)
+	}
+	return &FileReportQueue{dir: dir}, nil
+}
+
+// Report writes r to <dir>/<r.ID>.json.
+func (q *FileReportQueue) Report(r CyberTipReport) error {
+	if r.Reporter == (CyberTipReporter{}) {
+		r.Reporter = q.Reporter
+	}
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("moderation: encode report %s: %w", r.ID, err)
+	}
+
+	tmp, err := os.CreateTemp(q.dir, r.ID+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("moderation: create report %s: %w", r.ID, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return fmt.Errorf("moderation: write report %s: %w", r.ID, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("moderation: sync report %s: %w", r.ID, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("moderation: close report %s: %w", r.ID, err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(q.dir, r.ID+".json")); err != nil {
+		return fmt.Errorf("moderation: install report %s: %w", r.ID, err)
+	}
+	return nil
+}
+
+var _ CSAMReporter = (*FileReportQueue)(nil)
@@ -0,0 +1,197 @@
+package moderation
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCSAM_FastPathOnFirstFlag(t *testing.T) {
+	f := newModerationFixture()
+	err := f.queue.Submit(ContentFlag{ID: "f1", ContentID: "vid-1", Uploader: "mallory", FlaggedBy: "user-9", Category: CategoryCSAM, Evidence: "reported via in-player button"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !f.queue.IsEscalated("f1") {
+		t.Fatal("expected escalation on the first CSAM flag")
+	}
+	if denied, _ := f.deny.IsDenied("vid-1"); !denied {
+		t.Fatal("expected content denied pending review")
+	}
+	if err := f.strikes.CanUpload("mallory"); err == nil {
+		t.Fatal("expected uploader terminated")
+	}
+	if len(f.reporter.Reports) != 1 {
+		t.Fatalf("expected one CyberTipline report, got %d", len(f.reporter.Reports))
+	}
+	r := f.reporter.Reports[0]
+	if r.ReportedPerson.UploaderID != "mallory" || r.Files[0].ContentID != "vid-1" || r.Flag.FlagID != "f1" {
+		t.Fatalf("unexpected report %+v", r)
+	}
+	if !r.Preservation.PreserveUntil.Equal(r.CreatedAt.Add(CSAMPreservationPeriod)) {
+		t.Fatalf("unexpected preservation deadline %v", r.Preservation.PreserveUntil)
+	}
+
+	records, _ := f.audit.GetByFlag("f1")
+	if len(records) != 1 || records[0].Action != ActionCSAMEscalated {
+		t.Fatalf("expected csam_escalated audit record, got %+v", records)
+	}
+	if strings.Contains(records[0].Reason, "in-player") {
+		t.Fatal("evidence must not reach the audit log")
+	}
+}
+
+func TestCSAM_OtherCategoriesKeepThreshold(t *testing.T) {
+	f := newModerationFixture()
+	f.queue.Submit(ContentFlag{ID: "f1", ContentID: "vid-1", Uploader: "bob", Category: CategoryIllegal})
+
+	if f.queue.IsEscalated("f1") || len(f.reporter.Reports) != 0 {
+		t.Fatal("expected non-CSAM flag to wait for the escalation threshold")
+	}
+	if denied, _ := f.deny.IsDenied("vid-1"); denied {
+		t.Fatal("expected no pre-emptive denial")
+	}
+}
+
+func TestCSAM_DismissLiftsPreemptiveMeasures(t *testing.T) {
+	f := newModerationFixture()
+	f.queue.Submit(ContentFlag{ID: "f1", ContentID: "vid-1", Uploader: "bob", Category: CategoryCSAM})
+	f.queue.Review("f1", ActionDismiss, "mod-1")
+
+	if denied, _ := f.deny.IsDenied("vid-1"); denied {
+		t.Fatal("expected dismissed CSAM flag to restore content")
+	}
+	if err := f.strikes.CanUpload("bob"); err != nil {
+		t.Fatalf("expected uploader reinstated, got %v", err)
+	}
+}
+
+func TestCSAM_ClearingNeedsAnotherReviewer(t *testing.T) {
+	f := newModerationFixture()
+	f.queue.Submit(ContentFlag{ID: "f1", ContentID: "vid-1", Uploader: "bob", FlaggedBy: "mod-1", Category: CategoryCSAM})
+
+	if err := f.queue.Review("f1", ActionDismiss, ""); !errors.Is(err, ErrNoReviewer) {
+		t.Fatalf("expected ErrNoReviewer, got %v", err)
+	}
+	if err := f.queue.Review("f1", ActionApprove, "mod-1"); !errors.Is(err, ErrCSAMSameReviewer) {
+		t.Fatalf("expected ErrCSAMSameReviewer, got %v", err)
+	}
+	if denied, _ := f.deny.IsDenied("vid-1"); !denied {
+		t.Fatal("expected rejected reviews to leave the content denied")
+	}
+	if err := f.strikes.CanUpload("bob"); err == nil {
+		t.Fatal("expected rejected reviews to leave the uploader terminated")
+	}
+
+	if err := f.queue.Review("f1", ActionDismiss, "mod-2"); err != nil {
+		t.Fatal(err)
+	}
+	if denied, _ := f.deny.IsDenied("vid-1"); denied {
+		t.Fatal("expected another reviewer to clear the flag")
+	}
+}
+
+func TestCSAM_DismissKeepsOtherHolds(t *testing.T) {
+	tests := []struct {
+		name string
+		hold func(f *moderationFixture)
+	}{
+		{"dmca takedown", func(f *moderationFixture) {
+			NewDMCAService(f.deny, nil).SubmitNotice(validNotice("vid-1"))
+		}},
+		{"deny decision", func(f *moderationFixture) {
+			f.queue.Submit(ContentFlag{ID: "f2", ContentID: "vid-1", Category: CategoryIllegal})
+			f.queue.Review("f2", ActionDeny, "mod-1")
+		}},
+		{"second csam flag", func(f *moderationFixture) {
+			f.queue.Submit(ContentFlag{ID: "f2", ContentID: "vid-1", Category: CategoryCSAM})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newModerationFixture()
+			f.queue.Submit(ContentFlag{ID: "f1", ContentID: "vid-1", Uploader: "bob", Category: CategoryCSAM})
+			tt.hold(f)
+
+			if err := f.queue.Review("f1", ActionDismiss, "mod-1"); err != nil {
+				t.Fatal(err)
+			}
+			if denied, _ := f.deny.IsDenied("vid-1"); !denied {
+				t.Fatal("expected content still denied by the other hold")
+			}
+		})
+	}
+}
+
+// failingDenyList rejects every Add.
+type failingDenyList struct{ *MockDenyList }
+
+func (failingDenyList) Add(contentID, reason string) error { return errors.New("denylist unavailable") }
+
+func TestCSAM_FastPathReportsDespiteFailures(t *testing.T) {
+	reporter := NewMockCSAMReporter()
+	strikes := NewUploaderRegistry(DefaultStrikePolicy(), nil)
+	strikes.AddStrike(Strike{ID: "f1", Uploader: "mallory"})
+	q := NewMockModerationQueue(failingDenyList{NewMockDenyList()}, nil, DefaultEscalationConfig())
+	q.SetCSAMReporter(reporter)
+	q.SetStrikeLedger(strikes)
+
+	err := q.Submit(ContentFlag{ID: "f1", ContentID: "vid-1", Uploader: "mallory", Category: CategoryCSAM})
+	if err == nil || !strings.Contains(err.Error(), "denylist unavailable") || !errors.Is(err, ErrStrikeExists) {
+		t.Fatalf("expected deny and strike errors joined, got %v", err)
+	}
+	if len(reporter.Reports) != 1 || !q.IsEscalated("f1") {
+		t.Fatal("expected report exported and flag escalated despite the failures")
+	}
+}
+
+func TestCSAM_MissingReporter(t *testing.T) {
+	dl := NewMockDenyList()
+	q := NewMockModerationQueue(dl, nil, DefaultEscalationConfig())
+	err := q.Submit(ContentFlag{ID: "f1", ContentID: "vid-1", Category: CategoryCSAM})
+	if !errors.Is(err, ErrNoCSAMReporter) {
+		t.Fatalf("expected ErrNoCSAMReporter, got %v", err)
+	}
+	if denied, _ := dl.IsDenied("vid-1"); !denied {
+		t.Fatal("expected content denied without a reporter")
+	}
+}
+
+func TestFileReportQueue(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "cybertip")
+	q, err := NewFileReportQueue(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.Reporter = CyberTipReporter{ESPName: "FilStream", ContactEmail: "trust@example.com"}
+
+	r := NewCyberTipReport(ContentFlag{ID: "f1", ContentID: "vid-1", Category: CategoryCSAM}, time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC))
+	if err := q.Report(r); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "cybertip-f1.json")
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Fatalf("expected owner-only report file, got %v", perm)
+	}
+	b, _ := os.ReadFile(path)
+	var got CyberTipReport
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != r.ID || got.Reporter.ESPName != "FilStream" {
+		t.Fatalf("unexpected report on disk %+v", got)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Fatalf("expected no temp files left behind, got %d entries", len(entries))
+	}
+}
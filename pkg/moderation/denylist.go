@@ -0,0 +1,139 @@
+package moderation
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryDenyList is an in-memory DenyList, safe for concurrent use. It is
+// the production implementation for a single gateway; entries are loaded
+// at startup (see LoadDenyListFile) and kept current by moderation
+// services. Each distinct reason is a separate hold on the content, so
+// one service releasing its hold does not restore content another still
+// denies.
+type MemoryDenyList struct {
+	mu      sync.RWMutex
+	entries map[string]DenyEntry
+	now     func() time.Time
+}
+
+// NewMemoryDenyList creates an empty deny list.
+func NewMemoryDenyList() *MemoryDenyList {
+	return &MemoryDenyList{entries: make(map[string]DenyEntry), now: time.Now}
+}
+
+// Add places a hold on contentID for reason. Adding to already denied
+// content keeps its entry and adds reason to its Holds.
+func (d *MemoryDenyList) Add(contentID, reason string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	e, ok := d.entries[contentID]
+	if !ok {
+		e = DenyEntry{
+			ContentID: contentID,
+			Reason:    reason,
+			DeniedAt:  d.now(),
+			DeniedBy:  "system",
+		}
+	}
+	i := sort.SearchStrings(e.Holds, reason)
+	if i == len(e.Holds) || e.Holds[i] != reason {
+		e.Holds = append(e.Holds[:i:i], append([]string{reason}, e.Holds[i:]...)...)
+	}
+	d.entries[contentID] = e
+	return nil
+}
+
+// Release lifts the hold for reason on contentID. The content stays denied
+// while other holds remain. Releasing a hold that isn't there is a no-op.
+func (d *MemoryDenyList) Release(contentID, reason string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	e, ok := d.entries[contentID]
+	if !ok {
+		return nil
+	}
+	i := sort.SearchStrings(e.Holds, reason)
+	if i == len(e.Holds) || e.Holds[i] != reason {
+		return nil
+	}
+	e.Holds = append(e.Holds[:i:i], e.Holds[i+1:]...)
+	if len(e.Holds) == 0 {
+		delete(d.entries, contentID)
+		return nil
+	}
+	if e.Reason == reason {
+		e.Reason = e.Holds[0]
+	}
+	d.entries[contentID] = e
+	return nil
+}
+
+// Remove lifts the denial for contentID, dropping every hold.
+func (d *MemoryDenyList) Remove(contentID string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.entries[contentID]; !ok {
+		return fmt.Errorf("content %s not in denylist", contentID)
+	}
+	delete(d.entries, contentID)
+	return nil
+}
+
+// IsDenied reports whether contentID is denied.
+func (d *MemoryDenyList) IsDenied(contentID string) (bool, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	_, ok := d.entries[contentID]
+	return ok, nil
+}
+
+// List returns all entries sorted by content ID.
+func (d *MemoryDenyList) List() ([]DenyEntry, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	out := make([]DenyEntry, 0, len(d.entries))
+	for _, e := range d.entries {
+		e.Holds = append([]string(nil), e.Holds...)
+		out = append(out, e)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ContentID < out[j].ContentID })
+	return out, nil
+}
+
+// LoadDenyListFile adds every non-empty, non-comment (#) line of path to
+// dl with the given reason and returns how many were added.
+func LoadDenyListFile(dl DenyList, path, reason string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("moderation: open denylist: %w", err)
+	}
+	defer f.Close()
+
+	n := 0
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := dl.Add(line, reason); err != nil {
+			return n, fmt.Errorf("moderation: deny %s: %w", line, err)
+		}
+		n++
+	}
+	if err := sc.Err(); err != nil {
+		return n, fmt.Errorf("moderation: read denylist: %w", err)
+	}
+	return n, nil
+}
+
+var (
+	_ DenyList     = (*MemoryDenyList)(nil)
+	_ HoldReleaser = (*MemoryDenyList)(nil)
+)
@@ -0,0 +1,87 @@
+package moderation
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadDenyListFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deny.txt")
+	os.WriteFile(path, []byte("# takedowns\nbafyb\n\n  bafya  \n"), 0o600)
+
+	dl := NewMemoryDenyList()
+	n, err := LoadDenyListFile(dl, path, "denylist file")
+	if err != nil || n != 2 {
+		t.Fatalf("expected 2 entries loaded, got %d, %v", n, err)
+	}
+	entries, _ := dl.List()
+	if len(entries) != 2 || entries[0].ContentID != "bafya" || entries[1].Reason != "denylist file" {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+
+	if _, err := LoadDenyListFile(dl, filepath.Join(t.TempDir(), "missing"), ""); err == nil {
+		t.Fatal("expected error for missing file")
+	}
+	if err := dl.Remove("bafyc"); err == nil {
+		t.Fatal("expected error removing content that isn't denied")
+	}
+}
+
+func TestMemoryDenyList_Holds(t *testing.T) {
+	dl := NewMemoryDenyList()
+	dl.Add("vid-1", "dmca:n1")
+	dl.Add("vid-1", "flag:f1")
+	dl.Add("vid-1", "dmca:n1")
+
+	entries, _ := dl.List()
+	if len(entries) != 1 || !reflect.DeepEqual(entries[0].Holds, []string{"dmca:n1", "flag:f1"}) || entries[0].Reason != "dmca:n1" {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+
+	if err := dl.Release("vid-1", "dmca:n1"); err != nil {
+		t.Fatal(err)
+	}
+	if denied, _ := dl.IsDenied("vid-1"); !denied {
+		t.Fatal("expected content kept denied by the remaining hold")
+	}
+	if entries, _ := dl.List(); entries[0].Reason != "flag:f1" {
+		t.Fatalf("expected reason moved to the remaining hold, got %+v", entries[0])
+	}
+	if err := dl.Release("vid-1", "dmca:n1"); err != nil {
+		t.Fatalf("expected releasing an absent hold to be a no-op, got %v", err)
+	}
+	dl.Release("vid-1", "flag:f1")
+	if denied, _ := dl.IsDenied("vid-1"); denied {
+		t.Fatal("expected content restored once every hold is released")
+	}
+
+	dl.Add("vid-2", "a")
+	dl.Add("vid-2", "b")
+	if err := dl.Remove("vid-2"); err != nil {
+		t.Fatal(err)
+	}
+	if denied, _ := dl.IsDenied("vid-2"); denied {
+		t.Fatal("expected Remove to drop every hold")
+	}
+}
+
+// plainDenyList hides MemoryDenyList's Release, like an external DenyList.
+type plainDenyList struct{ DenyList }
+
+func TestReleaseHold_FallsBackToRemove(t *testing.T) {
+	dl := plainDenyList{NewMemoryDenyList()}
+	dl.Add("vid-1", "dmca:n1")
+	dl.Add("vid-1", "flag:f1")
+
+	if err := ReleaseHold(dl, "vid-1", "dmca:n1"); err != nil {
+		t.Fatal(err)
+	}
+	if denied, _ := dl.IsDenied("vid-1"); denied {
+		t.Fatal("expected Remove fallback to lift the denial")
+	}
+	if err := ReleaseHold(dl, "vid-1", "dmca:n1"); err != nil {
+		t.Fatalf("expected releasing content that isn't denied to be a no-op, got %v", err)
+	}
+}
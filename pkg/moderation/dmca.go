@@ -0,0 +1,371 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrInvalidNotice is matched by errors for notices or counter-notices
+	// missing required elements.
+	ErrInvalidNotice = errors.New("moderation: invalid DMCA notice")
+
+	// ErrNoticeNotFound is returned for an unknown notice ID.
+	ErrNoticeNotFound = errors.New("moderation: DMCA notice not found")
+
+	// ErrInvalidTransition is returned when an action doesn't apply to a
+	// notice's current status, e.g. a second counter-notice.
+	ErrInvalidTransition = errors.New("moderation: invalid DMCA transition")
+)
+
+// MissingElementsError lists the required elements absent from a notice
+// or counter-notice. It unwraps to ErrInvalidNotice.
+type MissingElementsError struct {
+	Missing []string
+}
+
+func (e *MissingElementsError) Error() string {
+	return fmt.Sprintf("moderation: DMCA notice missing required elements: %s", strings.Join(e.Missing, ", "))
+}
+
+func (e *MissingElementsError) Unwrap() error { return ErrInvalidNotice }
+
+// DMCAStatus is where a takedown is in the notice / counter-notice
+// lifecycle.
+type DMCAStatus string
+
+const (
+	DMCATakenDown      DMCAStatus = "taken_down"      // notice accepted, content denied
+	DMCACounterNoticed DMCAStatus = "counter_noticed" // restore scheduled at RestoreAfter
+	DMCACourtAction    DMCAStatus = "court_action"    // claimant sued; content stays down
+	DMCARestored       DMCAStatus = "restored"        // window elapsed, content restored
+)
+
+// DMCACase is the state of one takedown.
+type DMCACase struct {
+	Notice        DMCANotice         `json:"notice"`
+	CounterNotice *DMCACounterNotice `json:"counter_notice,omitempty"`
+	Status        DMCAStatus         `json:"status"`
+	UpdatedAt     time.Time          `json:"updated_at"`
+}
+
+// Validate checks the six elements 17 U.S.C. § 512(c)(3)(A) requires of a
+// takedown notice: signature, identification of the copyrighted work,
+// identification of the infringing material, contact information, the
+// good-faith statement, and the accuracy statement under penalty of perjury.
+func (n DMCANotice) Validate() error {
+	var missing []string
+	need := func(ok bool, element string) {
+		if !ok {
+			missing = append(missing, element)
+		}
+	}
+	need(present(n.Signature), "signature")
+	need(present(n.WorkDesc), "copyrighted work")
+	need(present(n.ContentID) || present(n.InfringingURL), "infringing material")
+	need(present(n.ClaimantName) &&
+		(present(n.ClaimantEmail) || present(n.ClaimantAddress) || present(n.ClaimantPhone)), "contact information")
+	need(present(n.Statement), "good-faith statement")
+	need(present(n.AccuracyStatement), "accuracy statement")
+	if len(missing) > 0 {
+		return &MissingElementsError{Missing: missing}
+	}
+	return nil
+}
+
+// Validate checks the elements 17 U.S.C. § 512(g)(3) requires of a
+// counter-notice: signature, identification of the removed material, the
+// statement under penalty of perjury, name, address and phone, and consent
+// to jurisdiction.
+func (c DMCACounterNotice) Validate() error {
+	var missing []string
+	need := func(ok bool, element string) {
+		if !ok {
+			missing = append(missing, element)
+		}
+	}
+	need(present(c.Signature), "signature")
+	need(present(c.NoticeID) || present(c.ContentID), "removed material")
+	need(present(c.Statement), "mistake statement")
+	need(present(c.ResponderName) && present(c.ResponderAddress) && present(c.ResponderPhone), "contact information")
+	need(c.ConsentToJurisdiction, "consent to jurisdiction")
+	if len(missing) > 0 {
+		return &MissingElementsError{Missing: missing}
+	}
+	return nil
+}
+
+func present(s string) bool { return strings.TrimSpace(s) != "" }
+
+// DMCAService runs the notice / counter-notice lifecycle:
+//
+//  1. SubmitNotice validates a notice and denies the content immediately.
+//  2. SubmitCounterNotice validates a counter-notice and schedules the
+//     restore for RestoreAfter, 10 business days out (see Calendar).
+//  3. RecordCourtAction freezes the restore while litigation is pending.
+//  4. RestoreDue (or Run) restores content whose window has elapsed.
+//
+// With a StrikeLedger set, a takedown of a notice naming its Uploader
+// issues a strike (ID = notice ID), and restoring after a counter-notice
+// reverses it.
+//
+// Each takedown holds its content on the DenyList under "dmca:<notice
+// ID>". With a HoldReleaser DenyList a restore releases only that hold, so
+// content also denied by another notice or a moderation decision stays
+// down.
+//
+// Every transition is written to the AuditLog with the notice ID as FlagID.
+// Audit and strike failures are returned after the transition has been
+// applied, so callers can alert without retrying it.
+//
+// Case state is kept in memory only. A restart loses counter-notice
+// schedules and court-action freezes; the takedowns themselves survive on
+// the DenyList (if it persists) and in the AuditLog, but their restores
+// must then be handled by hand.
+type DMCAService struct {
+	deny  DenyList
+	audit AuditLog
+
+	mu      sync.Mutex
+	cases   map[string]*DMCACase
+	seq     int
+	now     func() time.Time
+	cal     Calendar
+	strikes StrikeLedger
+}
+
+// NewDMCAService creates a service that enforces takedowns on deny and
+// records transitions to audit (which may be nil).
+func NewDMCAService(deny DenyList, audit AuditLog) *DMCAService {
+	return &DMCAService{
+		deny:  deny,
+		audit: audit,
+		cases: make(map[string]*DMCACase),
+		now:   time.Now,
+		cal:   DefaultBusinessCalendar(),
+	}
+}
+
+// SetClock replaces the service's time source. Intended for tests.
+func (s *DMCAService) SetClock(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
+// SetCalendar replaces the business-day calendar used for restore windows
+// (DefaultBusinessCalendar by default). Already scheduled restores keep
+// their window.
+func (s *DMCAService) SetCalendar(cal Calendar) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cal = cal
+}
+
+// SetStrikeLedger enables repeat-infringer strikes for takedowns.
+func (s *DMCAService) SetStrikeLedger(l StrikeLedger) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.strikes = l
+}
+
+// SubmitNotice validates n and takes the content down. ID and ReceivedAt
+// are filled in if empty; the stored notice is returned. If the takedown
+// was applied but its audit record or strike could not be written, the
+// notice is returned together with the error.
+func (s *DMCAService) SubmitNotice(n DMCANotice) (DMCANotice, error) {
+	if err := n.Validate(); err != nil {
+		return DMCANotice{}, err
+	}
+	if !present(n.ContentID) {
+		return DMCANotice{}, &MissingElementsError{Missing: []string{"content ID"}}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if n.ID == "" {
+		s.seq++
+		n.ID = fmt.Sprintf("dmca-%d", s.seq)
+	}
+	if _, dup := s.cases[n.ID]; dup {
+		return DMCANotice{}, fmt.Errorf("%w: notice %s already submitted", ErrInvalidTransition, n.ID)
+	}
+	if n.ReceivedAt.IsZero() {
+		n.ReceivedAt = now
+	}
+	if err := s.deny.Add(n.ContentID, dmcaHold(n.ID)); err != nil {
+		return DMCANotice{}, fmt.Errorf("moderation: deny %s: %w", n.ContentID, err)
+	}
+	s.cases[n.ID] = &DMCACase{Notice: n, Status: DMCATakenDown, UpdatedAt: now}
+	errs := []error{s.record(n.ID, n.ContentID, ActionDMCATakedown, n.ClaimantName, n.WorkDesc, now)}
+	if s.strikes != nil && n.Uploader != "" {
+		if _, err := s.strikes.AddStrike(Strike{ID: n.ID, Uploader: n.Uploader, ContentID: n.ContentID, Reason: dmcaHold(n.ID), IssuedAt: now}); err != nil {
+			errs = append(errs, fmt.Errorf("moderation: strike %s: %w", n.Uploader, err))
+		}
+	}
+	return n, errors.Join(errs...)
+}
+
+// SubmitCounterNotice validates c against its notice and schedules the
+// restore. RestoreAfter and RestoreBy are computed from ReceivedAt; the stored
+// counter-notice is returned.
+func (s *DMCAService) SubmitCounterNotice(c DMCACounterNotice) (DMCACounterNotice, error) {
+	if err := c.Validate(); err != nil {
+		return DMCACounterNotice{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cs, ok := s.cases[c.NoticeID]
+	if !ok {
+		return DMCACounterNotice{}, fmt.Errorf("%w: %s", ErrNoticeNotFound, c.NoticeID)
+	}
+	if cs.Status != DMCATakenDown {
+		return DMCACounterNotice{}, fmt.Errorf("%w: counter-notice for %s in status %s", ErrInvalidTransition, c.NoticeID, cs.Status)
+	}
+	if c.ContentID == "" {
+		c.ContentID = cs.Notice.ContentID
+	} else if c.ContentID != cs.Notice.ContentID {
+		return DMCACounterNotice{}, fmt.Errorf("%w: counter-notice content %s does not match notice %s", ErrInvalidNotice, c.ContentID, c.NoticeID)
+	}
+
+	now := s.now()
+	if c.ID == "" {
+		c.ID = c.NoticeID + "-counter"
+	}
+	if c.ReceivedAt.IsZero() {
+		c.ReceivedAt = now
+	}
+	c.RestoreAfter = s.cal.AddBusinessDays(c.ReceivedAt, DMCARestoreMinBusinessDays)
+	c.RestoreBy = s.cal.AddBusinessDays(c.ReceivedAt, DMCARestoreMaxBusinessDays)
+
+	stored := c
+	cs.CounterNotice = &stored
+	cs.Status = DMCACounterNoticed
+	cs.UpdatedAt = now
+	err := s.record(c.NoticeID, c.ContentID, ActionDMCACounterNotice, c.ResponderName,
+		"restore between "+c.RestoreAfter.Format(time.RFC3339)+" and "+c.RestoreBy.Format(time.RFC3339), now)
+	return c, err
+}
+
+// RecordCourtAction records that the claimant filed a court action, which
+// keeps the content down until the case is resolved outside this service.
+func (s *DMCAService) RecordCourtAction(noticeID, reportedBy string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cs, ok := s.cases[noticeID]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNoticeNotFound, noticeID)
+	}
+	if cs.Status != DMCACounterNoticed {
+		return fmt.Errorf("%w: court action for %s in status %s", ErrInvalidTransition, noticeID, cs.Status)
+	}
+	now := s.now()
+	cs.Status = DMCACourtAction
+	cs.UpdatedAt = now
+	return s.record(noticeID, cs.Notice.ContentID, ActionDMCACourtAction, reportedBy, "restoration frozen", now)
+}
+
+// RestoreDue releases the hold of every counter-noticed case whose
+// RestoreAfter has passed and returns the restored notice IDs, sorted.
+// With a HoldReleaser DenyList, content stays denied while any other hold
+// on it remains.
+func (s *DMCAService) RestoreDue() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	var due []string
+	for id, cs := range s.cases {
+		if cs.Status == DMCACounterNoticed && !now.Before(cs.CounterNotice.RestoreAfter) {
+			due = append(due, id)
+		}
+	}
+	sort.Strings(due)
+
+	var restored []string
+	var errs []error
+	for _, id := range due {
+		cs := s.cases[id]
+		if err := ReleaseHold(s.deny, cs.Notice.ContentID, dmcaHold(id)); err != nil {
+			// Left counter-noticed, so the next pass retries.
+			errs = append(errs, fmt.Errorf("moderation: restore %s: %w", cs.Notice.ContentID, err))
+			continue
+		}
+		cs.Status = DMCARestored
+		cs.UpdatedAt = now
+		restored = append(restored, id)
+		errs = append(errs, s.record(id, cs.Notice.ContentID, ActionDMCARestore, "system", "counter-notice window elapsed", now))
+		if s.strikes != nil && cs.Notice.Uploader != "" {
+			if err := s.strikes.ReverseStrike(id, "counter-notice: content restored"); err != nil && !errors.Is(err, ErrStrikeNotFound) {
+				errs = append(errs, fmt.Errorf("moderation: reverse strike %s: %w", id, err))
+			}
+		}
+	}
+	return restored, errors.Join(errs...)
+}
+
+// Run calls RestoreDue every interval until ctx is cancelled. Errors are
+// passed to onError (if not nil) and do not stop the loop.
+func (s *DMCAService) Run(ctx context.Context, interval time.Duration, onError func(error)) error {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			if _, err := s.RestoreDue(); err != nil && onError != nil {
+				onError(err)
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Case returns a copy of the case for noticeID.
+func (s *DMCAService) Case(noticeID string) (DMCACase, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cs, ok := s.cases[noticeID]
+	if !ok {
+		return DMCACase{}, false
+	}
+	out := *cs
+	if cs.CounterNotice != nil {
+		c := *cs.CounterNotice
+		out.CounterNotice = &c
+	}
+	return out, true
+}
+
+// dmcaHold is the DenyList reason a takedown holds its content under.
+func dmcaHold(noticeID string) string { return "dmca:" + noticeID }
+
+// record appends an audit record for a transition. Caller must hold s.mu.
+func (s *DMCAService) record(noticeID, contentID string, action ReviewAction, by, reason string, at time.Time) error {
+	if s.audit == nil {
+		return nil
+	}
+	err := s.audit.Append(AuditRecord{
+		ID:        fmt.Sprintf("audit-%s-%s", noticeID, action),
+		FlagID:    noticeID,
+		ContentID: contentID,
+		Action:    action,
+		ActionBy:  by,
+		Reason:    reason,
+		Timestamp: at,
+	})
+	if err != nil {
+		return fmt.Errorf("moderation: audit %s %s: %w", noticeID, action, err)
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+package moderation
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testClock struct{ t time.Time }
+
+func (c *testClock) Now() time.Time          { return c.t }
+func (c *testClock) Advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func validNotice(contentID string) DMCANotice {
+	return DMCANotice{
+		ContentID:         contentID,
+		ClaimantName:      "Rights Holder LLC",
+		ClaimantEmail:     "legal@example.com",
+		WorkDesc:          "Feature film X",
+		InfringingURL:     "https://filstream.example/content/" + contentID,
+		Statement:         "good faith belief",
+		AccuracyStatement: "accurate, under penalty of perjury",
+		Signature:         "/s/ Rights Holder",
+	}
+}
+
+func validCounter(noticeID string) DMCACounterNotice {
+	return DMCACounterNotice{
+		NoticeID:              noticeID,
+		ResponderName:         "Uploader",
+		ResponderAddress:      "1 Main St",
+		ResponderPhone:        "555-0100",
+		Statement:             "removed by mistake",
+		ConsentToJurisdiction: true,
+		Signature:             "/s/ Uploader",
+	}
+}
+
+func newTestDMCA() (*DMCAService, *MockDenyList, *MockAuditLog, *testClock) {
+	dl := NewMockDenyList()
+	al := NewMockAuditLog()
+	clock := &testClock{t: time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)}
+	s := NewDMCAService(dl, al)
+	s.SetClock(clock.Now)
+	return s, dl, al, clock
+}
+
+func TestDMCANotice_ValidateAllElements(t *testing.T) {
+	err := DMCANotice{}.Validate()
+	var me *MissingElementsError
+	if !errors.As(err, &me) || !errors.Is(err, ErrInvalidNotice) || len(me.Missing) != 6 {
+		t.Fatalf("expected six missing elements, got %v", err)
+	}
+
+	n := validNotice("vid-1")
+	n.ClaimantEmail = ""
+	n.ClaimantPhone = "555-0199" // any one contact channel is enough
+	if err := n.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	n.AccuracyStatement = " "
+	if err := n.Validate(); !errors.As(err, &me) || me.Missing[0] != "accuracy statement" {
+		t.Fatalf("expected missing accuracy statement, got %v", err)
+	}
+
+	c := validCounter("dmca-1")
+	c.ConsentToJurisdiction = false
+	if err := c.Validate(); !errors.Is(err, ErrInvalidNotice) {
+		t.Fatalf("expected counter-notice without consent rejected, got %v", err)
+	}
+}
+
+func TestDMCAService_TakedownCounterRestore(t *testing.T) {
+	s, dl, al, clock := newTestDMCA()
+
+	n, err := s.SubmitNotice(validNotice("vid-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if denied, _ := dl.IsDenied("vid-1"); !denied {
+		t.Fatal("expected immediate takedown")
+	}
+
+	clock.Advance(24 * time.Hour)
+	c, err := s.SubmitCounterNotice(validCounter(n.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Received Tue 3 Mar 2026: 10 business days is Tue 17 Mar, 14 is Mon 23 Mar.
+	wantAfter := time.Date(2026, 3, 17, 9, 0, 0, 0, time.UTC)
+	wantBy := time.Date(2026, 3, 23, 9, 0, 0, 0, time.UTC)
+	if !c.RestoreAfter.Equal(wantAfter) || !c.RestoreBy.Equal(wantBy) || c.ContentID != "vid-1" {
+		t.Fatalf("unexpected counter-notice %+v", c)
+	}
+	if _, err := s.SubmitCounterNotice(validCounter(n.ID)); !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("expected second counter-notice rejected, got %v", err)
+	}
+
+	clock.Advance(wantAfter.Sub(clock.Now()) - time.Minute)
+	if restored, _ := s.RestoreDue(); len(restored) != 0 {
+		t.Fatalf("restored too early: %v", restored)
+	}
+	clock.Advance(time.Minute)
+	if restored, _ := s.RestoreDue(); len(restored) != 1 || restored[0] != n.ID {
+		t.Fatalf("expected %s restored, got %v", n.ID, restored)
+	}
+	if denied, _ := dl.IsDenied("vid-1"); denied {
+		t.Fatal("expected content restored")
+	}
+
+	records, _ := al.GetByFlag(n.ID)
+	want := []ReviewAction{ActionDMCATakedown, ActionDMCACounterNotice, ActionDMCARestore}
+	if len(records) != len(want) {
+		t.Fatalf("expected %d audit records, got %+v", len(want), records)
+	}
+	for i, r := range records {
+		if r.Action != want[i] || r.ContentID != "vid-1" {
+			t.Fatalf("record %d: expected %s, got %+v", i, want[i], r)
+		}
+	}
+}
+
+func TestDMCAService_CourtActionFreezes(t *testing.T) {
+	s, dl, _, clock := newTestDMCA()
+	n, _ := s.SubmitNotice(validNotice("vid-1"))
+
+	if err := s.RecordCourtAction(n.ID, "claimant counsel"); !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("expected court action before counter-notice rejected, got %v", err)
+	}
+	s.SubmitCounterNotice(validCounter(n.ID))
+	if err := s.RecordCourtAction(n.ID, "claimant counsel"); err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Advance(30 * 24 * time.Hour)
+	if restored, _ := s.RestoreDue(); len(restored) != 0 {
+		t.Fatalf("expected no restore after court action, got %v", restored)
+	}
+	if denied, _ := dl.IsDenied("vid-1"); !denied {
+		t.Fatal("expected content to stay down")
+	}
+	if cs, _ := s.Case(n.ID); cs.Status != DMCACourtAction {
+		t.Fatalf("expected court_action status, got %s", cs.Status)
+	}
+}
+
+func TestDMCAService_OtherTakedownKeepsContentDown(t *testing.T) {
+	s, dl, _, clock := newTestDMCA()
+	a, _ := s.SubmitNotice(validNotice("vid-1"))
+	s.SubmitNotice(validNotice("vid-1"))
+	s.SubmitCounterNotice(validCounter(a.ID))
+
+	clock.Advance(14 * 24 * time.Hour)
+	if restored, _ := s.RestoreDue(); len(restored) != 1 {
+		t.Fatalf("expected first case restored, got %v", restored)
+	}
+	if denied, _ := dl.IsDenied("vid-1"); !denied {
+		t.Fatal("expected content still denied by the second notice")
+	}
+}
+
+func TestDMCAService_RestoreKeepsOtherHolds(t *testing.T) {
+	tests := []struct {
+		name string
+		hold func(q *MockModerationQueue)
+	}{
+		{"review deny", func(q *MockModerationQueue) {
+			q.Submit(ContentFlag{ID: "f1", ContentID: "vid-1", Category: CategoryCopyright})
+			q.Review("f1", ActionDeny, "mod-1")
+		}},
+		{"csam escalation", func(q *MockModerationQueue) {
+			q.Submit(ContentFlag{ID: "f1", ContentID: "vid-1", Category: CategoryCSAM})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, dl, al, clock := newTestDMCA()
+			q := NewMockModerationQueue(dl, al, DefaultEscalationConfig())
+			q.SetCSAMReporter(NewMockCSAMReporter())
+			n, _ := s.SubmitNotice(validNotice("vid-1"))
+			tt.hold(q)
+			s.SubmitCounterNotice(validCounter(n.ID))
+
+			clock.Advance(14 * 24 * time.Hour)
+			if restored, err := s.RestoreDue(); err != nil || len(restored) != 1 {
+				t.Fatalf("expected takedown restored, got %v, %v", restored, err)
+			}
+			if denied, _ := dl.IsDenied("vid-1"); !denied {
+				t.Fatal("expected content still denied by the other hold")
+			}
+		})
+	}
+}
+
+// failingAuditLog rejects every append.
+type failingAuditLog struct{ *MockAuditLog }
+
+func (failingAuditLog) Append(AuditRecord) error { return errors.New("disk full") }
+
+func TestDMCAService_ReturnsAuditAndStrikeErrors(t *testing.T) {
+	dl := NewMockDenyList()
+	s := NewDMCAService(dl, failingAuditLog{NewMockAuditLog()})
+	s.SetStrikeLedger(NewUploaderRegistry(DefaultStrikePolicy(), nil))
+
+	notice := validNotice("vid-1")
+	notice.ID, notice.Uploader = "dmca-1", "erin"
+	n, err := s.SubmitNotice(notice)
+	if err == nil || !strings.Contains(err.Error(), "disk full") || n.ID != "dmca-1" {
+		t.Fatalf("expected notice returned with audit error, got %+v, %v", n, err)
+	}
+	if denied, _ := dl.IsDenied("vid-1"); !denied {
+		t.Fatal("expected takedown applied despite audit failure")
+	}
+
+	// A strike the ledger rejects is reported as well.
+	other := NewDMCAService(dl, nil)
+	ledger := NewUploaderRegistry(DefaultStrikePolicy(), nil)
+	ledger.AddStrike(Strike{ID: "dmca-1", Uploader: "erin"})
+	other.SetStrikeLedger(ledger)
+	if _, err := other.SubmitNotice(notice); !errors.Is(err, ErrStrikeExists) {
+		t.Fatalf("expected strike error returned, got %v", err)
+	}
+}
+
+func TestDMCAService_UnknownNotice(t *testing.T) {
+	s, _, _, _ := newTestDMCA()
+	if _, err := s.SubmitCounterNotice(validCounter("dmca-404")); !errors.Is(err, ErrNoticeNotFound) {
+		t.Fatalf("expected ErrNoticeNotFound, got %v", err)
+	}
+}
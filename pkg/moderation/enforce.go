@@ -0,0 +1,114 @@
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/quriustus/filstream-curio-adapter/pkg/adapter"
+)
+
+// DeniedError is returned by EnforcingRetriever for denied content. It
+// unwraps to ErrContentDenied.
+type DeniedError struct {
+	ContentID string
+}
+
+func (e *DeniedError) Error() string {
+	return fmt.Sprintf("content %s denied by moderation policy", e.ContentID)
+}
+
+func (e *DeniedError) Unwrap() error { return ErrContentDenied }
+
+// EnforcingRetriever wraps a RetrieverAPI and refuses to serve denied
+// content, implementing the seeder rule "run MayContain before serving any
+// content segment". The Bloom filter is checked first; only positives are
+// confirmed against the authoritative DenyList, so false positives are still
+// served. Every blocked attempt is written to the AuditLog.
+type EnforcingRetriever struct {
+	next  adapter.RetrieverAPI
+	deny  DenyList
+	audit AuditLog
+
+	mu    sync.RWMutex
+	bloom *DenylistBloom
+
+	seq uint64
+}
+
+// NewEnforcingRetriever wraps next. bloom may be nil, in which case every
+// request is checked against deny. audit may be nil.
+func NewEnforcingRetriever(next adapter.RetrieverAPI, deny DenyList, bloom *DenylistBloom, audit AuditLog) *EnforcingRetriever {
+	return &EnforcingRetriever{next: next, deny: deny, bloom: bloom, audit: audit}
+}
+
+// SetBloom replaces the Bloom filter, e.g. after a denylist sync.
+func (e *EnforcingRetriever) SetBloom(bloom *DenylistBloom) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.bloom = bloom
+}
+
+// Get retrieves the full content for cid unless it is denied.
+func (e *EnforcingRetriever) Get(ctx context.Context, cid string) (io.ReadCloser, error) {
+	if err := e.check(cid, "get"); err != nil {
+		return nil, err
+	}
+	return e.next.Get(ctx, cid)
+}
+
+// GetRange retrieves [start, end) for cid unless it is denied.
+func (e *EnforcingRetriever) GetRange(ctx context.Context, cid string, start, end uint64) (io.ReadCloser, error) {
+	if err := e.check(cid, fmt.Sprintf("range [%d, %d)", start, end)); err != nil {
+		return nil, err
+	}
+	return e.next.GetRange(ctx, cid, start, end)
+}
+
+// Stat passes through to the wrapped retriever unless cid is denied.
+func (e *EnforcingRetriever) Stat(ctx context.Context, cid string) (adapter.ObjectInfo, error) {
+	if err := e.check(cid, "stat"); err != nil {
+		return adapter.ObjectInfo{}, err
+	}
+	return adapter.StatOf(ctx, e.next, cid)
+}
+
+// check returns a *DeniedError if cid is denied. A DenyList failure on a
+// Bloom positive fails closed.
+func (e *EnforcingRetriever) check(cid, op string) error {
+	e.mu.RLock()
+	bloom := e.bloom
+	e.mu.RUnlock()
+
+	if bloom != nil && !bloom.MayContain(cid) {
+		return nil
+	}
+	denied, err := e.deny.IsDenied(cid)
+	if err != nil {
+		return fmt.Errorf("moderation: denylist lookup for %s: %w", cid, err)
+	}
+	if !denied {
+		return nil
+	}
+
+	if e.audit != nil {
+		_ = e.audit.Append(AuditRecord{
+			ID:        fmt.Sprintf("audit-serve-%d", atomic.AddUint64(&e.seq, 1)),
+			ContentID: cid,
+			Action:    ActionServeBlocked,
+			ActionBy:  "system",
+			Reason:    "denied content requested: " + op,
+			Timestamp: time.Now(),
+		})
+	}
+	return &DeniedError{ContentID: cid}
+}
+
+// Compile-time interface checks.
+var (
+	_ adapter.RetrieverAPI = (*EnforcingRetriever)(nil)
+	_ adapter.Stater       = (*EnforcingRetriever)(nil)
+)
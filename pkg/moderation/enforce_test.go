@@ -0,0 +1,106 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/quriustus/filstream-curio-adapter/internal/mock"
+)
+
+// countingDenyList counts authoritative lookups.
+type countingDenyList struct {
+	*MockDenyList
+	lookups int
+}
+
+func (c *countingDenyList) IsDenied(contentID string) (bool, error) {
+	c.lookups++
+	return c.MockDenyList.IsDenied(contentID)
+}
+
+func TestEnforcingRetriever_BlocksDenied(t *testing.T) {
+	dl := NewMockDenyList()
+	al := NewMockAuditLog()
+	_ = dl.Add("bafydeadbeef", "copyright")
+	bloom := NewDenylistBloom(1000, 0.01)
+	bloom.Add("bafydeadbeef")
+
+	r := NewEnforcingRetriever(mock.NewBackend(), dl, bloom, al)
+	ctx := context.Background()
+
+	_, err := r.GetRange(ctx, "bafydeadbeef", 0, 5)
+	if !errors.Is(err, ErrContentDenied) {
+		t.Fatalf("expected ErrContentDenied, got %v", err)
+	}
+	var de *DeniedError
+	if !errors.As(err, &de) || de.ContentID != "bafydeadbeef" {
+		t.Fatalf("expected *DeniedError, got %v", err)
+	}
+
+	records, _ := al.GetByContent("bafydeadbeef")
+	if len(records) != 1 || records[0].Action != ActionServeBlocked {
+		t.Fatalf("expected 1 serve_blocked audit record, got %+v", records)
+	}
+
+	// Other content is still served.
+	rc, err := r.Get(ctx, "bafy5678chunk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc.Close()
+}
+
+func TestEnforcingRetriever_BloomShortCircuit(t *testing.T) {
+	dl := &countingDenyList{MockDenyList: NewMockDenyList()}
+	bloom := NewDenylistBloom(1000, 0.01)
+	r := NewEnforcingRetriever(mock.NewBackend(), dl, bloom, nil)
+
+	rc, err := r.Get(context.Background(), "bafydeadbeef")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(data) != "hello filstream" {
+		t.Fatalf("unexpected data: %q", data)
+	}
+	if dl.lookups != 0 {
+		t.Fatalf("expected Bloom miss to skip the denylist, got %d lookups", dl.lookups)
+	}
+}
+
+func TestEnforcingRetriever_BloomFalsePositiveServed(t *testing.T) {
+	dl := &countingDenyList{MockDenyList: NewMockDenyList()}
+	bloom := NewDenylistBloom(1000, 0.01)
+	bloom.Add("bafydeadbeef") // in the filter but not on the denylist
+	r := NewEnforcingRetriever(mock.NewBackend(), dl, bloom, nil)
+
+	rc, err := r.Get(context.Background(), "bafydeadbeef")
+	if err != nil {
+		t.Fatalf("expected false positive to be served, got %v", err)
+	}
+	rc.Close()
+	if dl.lookups != 1 {
+		t.Fatalf("expected positive confirmed against denylist, got %d lookups", dl.lookups)
+	}
+}
+
+func TestEnforcingRetriever_NilBloomAndSetBloom(t *testing.T) {
+	dl := NewMockDenyList()
+	_ = dl.Add("bafydeadbeef", "abuse")
+	r := NewEnforcingRetriever(mock.NewBackend(), dl, nil, nil)
+
+	if _, err := r.Stat(context.Background(), "bafydeadbeef"); !errors.Is(err, ErrContentDenied) {
+		t.Fatalf("expected ErrContentDenied without bloom, got %v", err)
+	}
+
+	// An empty synced filter means nothing is denied as far as the seeder knows.
+	r.SetBloom(NewDenylistBloom(1000, 0.01))
+	rc, err := r.Get(context.Background(), "bafydeadbeef")
+	if err != nil {
+		t.Fatalf("expected Bloom miss to serve, got %v", err)
+	}
+	rc.Close()
+}
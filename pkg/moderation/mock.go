@@ -1,6 +1,7 @@
 package moderation
 
 import (
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -8,51 +9,11 @@ import (
 
 // MockDenyList is an in-memory DenyList for testing.
 type MockDenyList struct {
-	mu      sync.RWMutex
-	entries map[string]DenyEntry
+	*MemoryDenyList
 }
 
 func NewMockDenyList() *MockDenyList {
-	return &MockDenyList{entries: make(map[string]DenyEntry)}
-}
-
-func (m *MockDenyList) Add(contentID, reason string) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	m.entries[contentID] = DenyEntry{
-		ContentID: contentID,
-		Reason:    reason,
-		DeniedAt:  time.Now(),
-		DeniedBy:  "system",
-	}
-	return nil
-}
-
-func (m *MockDenyList) Remove(contentID string) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	if _, ok := m.entries[contentID]; !ok {
-		return fmt.Errorf("content %s not in denylist", contentID)
-	}
-	delete(m.entries, contentID)
-	return nil
-}
-
-func (m *MockDenyList) IsDenied(contentID string) (bool, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-	_, ok := m.entries[contentID]
-	return ok, nil
-}
-
-func (m *MockDenyList) List() ([]DenyEntry, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-	out := make([]DenyEntry, 0, len(m.entries))
-	for _, e := range m.entries {
-		out = append(out, e)
-	}
-	return out, nil
+	return &MockDenyList{NewMemoryDenyList()}
 }
 
 // MockModerationQueue is an in-memory ModerationQueue for testing.
@@ -63,6 +24,8 @@ type MockModerationQueue struct {
 	reviewed  map[string]ReviewAction
 	denyList  DenyList
 	auditLog  AuditLog
+	strikes   StrikeLedger
+	reporter  CSAMReporter
 	escConfig EscalationConfig
 	// track flags per content for auto-escalation
 	contentFlags map[string][]time.Time
@@ -80,6 +43,22 @@ func NewMockModerationQueue(dl DenyList, al AuditLog, cfg EscalationConfig) *Moc
 	}
 }
 
+// SetStrikeLedger makes ActionDeny reviews issue a strike (ID = flag ID)
+// against the flag's Uploader.
+func (m *MockModerationQueue) SetStrikeLedger(l StrikeLedger) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.strikes = l
+}
+
+// SetCSAMReporter sets where CyberTipline reports for CSAM flags go.
+// Without one, Submit of a CSAM flag returns ErrNoCSAMReporter.
+func (m *MockModerationQueue) SetCSAMReporter(r CSAMReporter) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reporter = r
+}
+
 func (m *MockModerationQueue) Submit(flag ContentFlag) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -91,6 +70,10 @@ func (m *MockModerationQueue) Submit(flag ContentFlag) error {
 	}
 	m.flags[flag.ID] = flag
 
+	if flag.Category == CategoryCSAM {
+		return m.escalateCSAM(flag)
+	}
+
 	// Auto-escalation check
 	now := time.Now()
 	cutoff := now.Add(-m.escConfig.Window)
@@ -111,6 +94,10 @@ func (m *MockModerationQueue) Submit(flag ContentFlag) error {
 	return nil
 }
 
+// Review records a moderator's decision on a flag. Approving or
+// dismissing a CSAM flag lifts the fast path's measures, so it needs a
+// named reviewer other than the flag's FlaggedBy (ErrNoReviewer,
+// ErrCSAMSameReviewer).
 func (m *MockModerationQueue) Review(flagID string, action ReviewAction, reviewedBy string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -118,24 +105,100 @@ func (m *MockModerationQueue) Review(flagID string, action ReviewAction, reviewe
 	if !ok {
 		return fmt.Errorf("flag %s not found", flagID)
 	}
+	clearsCSAM := flag.Category == CategoryCSAM && (action == ActionApprove || action == ActionDismiss)
+	if clearsCSAM && reviewedBy == "" {
+		return fmt.Errorf("%w: flag %s", ErrNoReviewer, flagID)
+	}
+	if clearsCSAM && reviewedBy == flag.FlaggedBy {
+		return fmt.Errorf("%w: %s on flag %s", ErrCSAMSameReviewer, reviewedBy, flagID)
+	}
 	m.reviewed[flagID] = action
 
+	// A CSAM flag found invalid lifts the fast path's pre-emptive measures:
+	// its own hold on the content, which stays denied if anything else
+	// holds it, and the terminal strike.
+	var errs []error
+	if clearsCSAM {
+		if m.denyList != nil {
+			if err := ReleaseHold(m.denyList, flag.ContentID, flagHold(CategoryCSAM, flagID)); err != nil {
+				errs = append(errs, fmt.Errorf("moderation: restore %s: %w", flag.ContentID, err))
+			}
+		}
+		if m.strikes != nil && flag.Uploader != "" {
+			if err := m.strikes.ReverseStrike(flagID, "csam flag "+string(action)); err != nil && !errors.Is(err, ErrStrikeNotFound) {
+				errs = append(errs, fmt.Errorf("moderation: reverse strike %s: %w", flagID, err))
+			}
+		}
+	}
+
 	if action == ActionDeny && m.denyList != nil {
-		_ = m.denyList.Add(flag.ContentID, string(flag.Category))
+		if err := m.denyList.Add(flag.ContentID, flagHold(flag.Category, flagID)); err != nil {
+			errs = append(errs, fmt.Errorf("moderation: deny %s: %w", flag.ContentID, err))
+		}
+	}
+	if action == ActionDeny && m.strikes != nil && flag.Uploader != "" {
+		if _, err := m.strikes.AddStrike(Strike{ID: flagID, Uploader: flag.Uploader, ContentID: flag.ContentID, Reason: string(flag.Category)}); err != nil {
+			errs = append(errs, fmt.Errorf("moderation: strike %s: %w", flag.Uploader, err))
+		}
 	}
 
 	if m.auditLog != nil {
-		_ = m.auditLog.Append(AuditRecord{
+		if err := m.auditLog.Append(AuditRecord{
 			ID:        fmt.Sprintf("audit-%s", flagID),
 			FlagID:    flagID,
 			ContentID: flag.ContentID,
+			Category:  flag.Category,
 			Action:    action,
 			ActionBy:  reviewedBy,
 			Reason:    string(flag.Category),
 			Timestamp: time.Now(),
-		})
+		}); err != nil {
+			errs = append(errs, fmt.Errorf("moderation: audit %s: %w", flagID, err))
+		}
 	}
-	return nil
+	return errors.Join(errs...)
+}
+
+// escalateCSAM is the CSAM fast path, taken on the first flag with no
+// threshold: a CyberTipline report is exported, the flag escalated, the
+// content denied pending review (hold "csam:<flag ID>") and the uploader
+// terminated. The report goes first and every step is attempted even if
+// an earlier one fails; failures, including a missing CSAMReporter, are
+// returned together. Audit records carry only the category, never
+// evidence. Caller must hold m.mu.
+func (m *MockModerationQueue) escalateCSAM(flag ContentFlag) error {
+	var errs []error
+	if m.reporter == nil {
+		errs = append(errs, fmt.Errorf("%w: flag %s", ErrNoCSAMReporter, flag.ID))
+	} else if err := m.reporter.Report(NewCyberTipReport(flag, time.Now())); err != nil {
+		errs = append(errs, fmt.Errorf("moderation: report flag %s: %w", flag.ID, err))
+	}
+	m.escalated[flag.ID] = true
+	if m.denyList != nil {
+		if err := m.denyList.Add(flag.ContentID, flagHold(CategoryCSAM, flag.ID)); err != nil {
+			errs = append(errs, fmt.Errorf("moderation: deny %s: %w", flag.ContentID, err))
+		}
+	}
+	if m.strikes != nil && flag.Uploader != "" {
+		if _, err := m.strikes.AddStrike(Strike{ID: flag.ID, Uploader: flag.Uploader, ContentID: flag.ContentID, Reason: string(CategoryCSAM), Terminal: true}); err != nil {
+			errs = append(errs, fmt.Errorf("moderation: strike %s: %w", flag.Uploader, err))
+		}
+	}
+	if m.auditLog != nil {
+		if err := m.auditLog.Append(AuditRecord{
+			ID:        fmt.Sprintf("audit-%s-%s", flag.ID, ActionCSAMEscalated),
+			FlagID:    flag.ID,
+			ContentID: flag.ContentID,
+			Category:  CategoryCSAM,
+			Action:    ActionCSAMEscalated,
+			ActionBy:  "system",
+			Reason:    string(CategoryCSAM),
+			Timestamp: time.Now(),
+		}); err != nil {
+			errs = append(errs, fmt.Errorf("moderation: audit flag %s: %w", flag.ID, err))
+		}
+	}
+	return errors.Join(errs...)
 }
 
 func (m *MockModerationQueue) Escalate(flagID string) error {
@@ -166,6 +229,23 @@ func (m *MockModerationQueue) GetPending() ([]ContentFlag, error) {
 	return out, nil
 }
 
+// MockCSAMReporter records CyberTipline reports for testing.
+type MockCSAMReporter struct {
+	mu      sync.Mutex
+	Reports []CyberTipReport
+}
+
+func NewMockCSAMReporter() *MockCSAMReporter {
+	return &MockCSAMReporter{}
+}
+
+func (m *MockCSAMReporter) Report(r CyberTipReport) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Reports = append(m.Reports, r)
+	return nil
+}
+
 // MockSyncBroadcaster records broadcast calls for testing.
 type MockSyncBroadcaster struct {
 	mu          sync.Mutex
@@ -5,9 +5,14 @@
 package moderation
 
 import (
+	"errors"
 	"time"
 )
 
+// ErrContentDenied is returned when content is on the denylist and must not
+// be served.
+var ErrContentDenied = errors.New("content denied by moderation policy")
+
 // FlagCategory classifies the type of content violation.
 type FlagCategory string
 
@@ -15,6 +20,7 @@ const (
 	CategoryCopyright FlagCategory = "copyright"
 	CategoryIllegal   FlagCategory = "illegal"
 	CategoryAbuse     FlagCategory = "abuse"
+	CategoryCSAM      FlagCategory = "csam" // child sexual abuse material: escalated and reported at once, never appealable
 )
 
 // ReviewAction represents the outcome of reviewing a content flag.
@@ -24,64 +30,106 @@ const (
 	ActionApprove ReviewAction = "approve" // content is fine, dismiss flag
 	ActionDeny    ReviewAction = "deny"    // add to denylist
 	ActionDismiss ReviewAction = "dismiss" // flag invalid, no action
+
+	// ActionServeBlocked records an attempt to retrieve denied content.
+	ActionServeBlocked ReviewAction = "serve_blocked"
+
+	// DMCA lifecycle transitions, recorded by DMCAService.
+	ActionDMCATakedown      ReviewAction = "dmca_takedown"
+	ActionDMCACounterNotice ReviewAction = "dmca_counter_notice"
+	ActionDMCACourtAction   ReviewAction = "dmca_court_action"
+	ActionDMCARestore       ReviewAction = "dmca_restore"
+
+	// Repeat-infringer strikes, recorded by UploaderRegistry.
+	ActionStrike         ReviewAction = "strike"
+	ActionStrikeReversed ReviewAction = "strike_reversed"
+
+	// ActionCSAMEscalated records the CSAM fast path: immediate
+	// escalation, pre-emptive denial and a CyberTipline report.
+	ActionCSAMEscalated ReviewAction = "csam_escalated"
+
+	// Appeal transitions, recorded by AppealService.
+	ActionAppealFiled    ReviewAction = "appeal_filed"
+	ActionAppealUpheld   ReviewAction = "appeal_upheld"
+	ActionAppealReversed ReviewAction = "appeal_reversed"
 )
 
 // ContentFlag represents a report against a piece of content.
 type ContentFlag struct {
 	ID        string       `json:"id"`
 	ContentID string       `json:"content_id"`
+	Uploader  string       `json:"uploader,omitempty"` // who uploaded the content, for strikes
 	FlaggedBy string       `json:"flagged_by"`
 	Category  FlagCategory `json:"category"`
 	Evidence  string       `json:"evidence"`
 	Timestamp time.Time    `json:"timestamp"`
 }
 
-// DenyEntry is a record in the denylist.
+// DenyEntry is a record in the denylist. Holds lists every reason the
+// content is currently denied for; Reason is one of them.
 type DenyEntry struct {
 	ContentID string    `json:"content_id"`
 	Reason    string    `json:"reason"`
+	Holds     []string  `json:"holds,omitempty"`
 	DeniedAt  time.Time `json:"denied_at"`
 	DeniedBy  string    `json:"denied_by"`
 }
 
 // AuditRecord captures every moderation action for accountability.
+// Category is set on records of decisions about a ContentFlag.
 type AuditRecord struct {
-	ID         string       `json:"id"`
-	FlagID     string       `json:"flag_id"`
-	ContentID  string       `json:"content_id"`
-	Action     ReviewAction `json:"action"`
-	ActionBy   string       `json:"action_by"`
-	Reason     string       `json:"reason"`
-	Timestamp  time.Time    `json:"timestamp"`
+	ID        string       `json:"id"`
+	FlagID    string       `json:"flag_id"`
+	ContentID string       `json:"content_id"`
+	Category  FlagCategory `json:"category,omitempty"`
+	Action    ReviewAction `json:"action"`
+	ActionBy  string       `json:"action_by"`
+	Reason    string       `json:"reason"`
+	Timestamp time.Time    `json:"timestamp"`
+}
+
+// flagHold is the DenyList reason a decision on a flag holds its content
+// under, e.g. "copyright:flag-1".
+func flagHold(category FlagCategory, flagID string) string {
+	return string(category) + ":" + flagID
 }
 
 // DMCANotice represents a DMCA takedown request per 17 U.S.C. § 512.
 type DMCANotice struct {
-	ID            string    `json:"id"`
-	ContentID     string    `json:"content_id"`
-	ClaimantName  string    `json:"claimant_name"`
-	ClaimantEmail string    `json:"claimant_email"`
-	WorkDesc      string    `json:"work_description"`    // description of copyrighted work
-	InfringingURL string    `json:"infringing_url"`
-	Statement     string    `json:"statement"`           // good-faith statement
-	Signature     string    `json:"signature"`
-	ReceivedAt    time.Time `json:"received_at"`
+	ID                string    `json:"id"`
+	ContentID         string    `json:"content_id"`
+	Uploader          string    `json:"uploader,omitempty"` // filled in from upload records, for strikes
+	ClaimantName      string    `json:"claimant_name"`
+	ClaimantEmail     string    `json:"claimant_email"`
+	ClaimantAddress   string    `json:"claimant_address,omitempty"`
+	ClaimantPhone     string    `json:"claimant_phone,omitempty"`
+	WorkDesc          string    `json:"work_description"` // description of copyrighted work
+	InfringingURL     string    `json:"infringing_url"`
+	Statement         string    `json:"statement"`          // good-faith statement
+	AccuracyStatement string    `json:"accuracy_statement"` // accuracy + authority, under penalty of perjury
+	Signature         string    `json:"signature"`
+	ReceivedAt        time.Time `json:"received_at"`
 }
 
 // DMCACounterNotice represents a counter-notification from the content uploader.
 // Per DMCA, the service provider must wait 10 business days after receiving a
 // counter-notice before restoring content (unless claimant files court action).
 type DMCACounterNotice struct {
-	ID             string    `json:"id"`
-	NoticeID       string    `json:"notice_id"`        // references DMCANotice.ID
-	ContentID      string    `json:"content_id"`
-	ResponderName  string    `json:"responder_name"`
-	ResponderEmail string    `json:"responder_email"`
-	Statement      string    `json:"statement"`
-	Signature      string    `json:"signature"`
-	ReceivedAt     time.Time `json:"received_at"`
-	// RestoreAfter is 10 business days from ReceivedAt (set by the system).
-	RestoreAfter   time.Time `json:"restore_after"`
+	ID                    string    `json:"id"`
+	NoticeID              string    `json:"notice_id"` // references DMCANotice.ID
+	ContentID             string    `json:"content_id"`
+	ResponderName         string    `json:"responder_name"`
+	ResponderEmail        string    `json:"responder_email"`
+	ResponderAddress      string    `json:"responder_address"`
+	ResponderPhone        string    `json:"responder_phone"`
+	Statement             string    `json:"statement"` // mistake/misidentification, under penalty of perjury
+	ConsentToJurisdiction bool      `json:"consent_to_jurisdiction"`
+	Signature             string    `json:"signature"`
+	ReceivedAt            time.Time `json:"received_at"`
+	// RestoreAfter and RestoreBy bound the restore window: 10 and 14
+	// business days from ReceivedAt (set by the system).
+	RestoreAfter time.Time `json:"restore_after"`
+	RestoreBy    time.Time `json:"restore_by"`
 }
 
 // EscalationConfig controls auto-escalation thresholds.
@@ -100,8 +148,12 @@ func DefaultEscalationConfig() EscalationConfig {
 	}
 }
 
-// DMCARestorePeriod is the 10-business-day waiting period for counter-notices.
-const DMCARestorePeriod = 10 * 24 * time.Hour // simplified to 10 calendar days
+// DMCARestorePeriod approximates the counter-notice waiting period as 10
+// calendar days.
+//
+// Deprecated: the window is 10–14 business days; use a Calendar with
+// DMCARestoreMinBusinessDays and DMCARestoreMaxBusinessDays.
+const DMCARestorePeriod = 10 * 24 * time.Hour
 
 // DenyList manages a set of denied content IDs. Implementations must be
 // safe for concurrent use.
@@ -112,6 +164,26 @@ type DenyList interface {
 	List() ([]DenyEntry, error)
 }
 
+// HoldReleaser is optionally implemented by a DenyList that keeps each
+// reason passed to Add as a separate hold. Release lifts one hold; the
+// content stays denied until none remain.
+type HoldReleaser interface {
+	Release(contentID, reason string) error
+}
+
+// ReleaseHold calls Release if dl implements HoldReleaser. Otherwise it
+// falls back to Remove, lifting every hold, if the content is denied.
+func ReleaseHold(dl DenyList, contentID, reason string) error {
+	if hr, ok := dl.(HoldReleaser); ok {
+		return hr.Release(contentID, reason)
+	}
+	denied, err := dl.IsDenied(contentID)
+	if err != nil || !denied {
+		return err
+	}
+	return dl.Remove(contentID)
+}
+
 // ModerationQueue handles the lifecycle of content flags.
 type ModerationQueue interface {
 	Submit(flag ContentFlag) error
@@ -120,6 +192,28 @@ type ModerationQueue interface {
 	GetPending() ([]ContentFlag, error)
 }
 
+// StrikeLedger tracks repeat-infringer strikes per uploader.
+// Implementations must be safe for concurrent use.
+type StrikeLedger interface {
+	AddStrike(s Strike) (UploaderStatus, error)
+	ReverseStrike(strikeID, reason string) error
+	Status(uploader string) UploaderStatus
+	CanUpload(uploader string) error
+}
+
+// AppealQueue handles appeals of moderation decisions, identified by the
+// ID of the decision's AuditRecord.
+type AppealQueue interface {
+	File(decisionID, appellant, statement string) (Appeal, error)
+	Review(appealID string, outcome AppealStatus, reviewedBy, notes string) error
+	Pending() ([]Appeal, error)
+}
+
+// CSAMReporter exports CyberTipline reports for apparent CSAM.
+type CSAMReporter interface {
+	Report(r CyberTipReport) error
+}
+
 // SyncBroadcaster propagates denylist updates to seeder nodes.
 type SyncBroadcaster interface {
 	BroadcastDenylist(seederIDs []string) error
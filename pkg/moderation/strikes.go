@@ -0,0 +1,266 @@
+package moderation
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrUploadRestricted is matched by CanUpload errors for uploaders in
+	// a temporary upload restriction.
+	ErrUploadRestricted = errors.New("moderation: uploads restricted")
+
+	// ErrUploaderTerminated is returned by CanUpload for terminated
+	// uploaders.
+	ErrUploaderTerminated = errors.New("moderation: uploader terminated")
+
+	// ErrStrikeNotFound is returned for an unknown strike ID.
+	ErrStrikeNotFound = errors.New("moderation: strike not found")
+
+	// ErrStrikeExists is returned when a strike ID is issued twice.
+	ErrStrikeExists = errors.New("moderation: strike already issued")
+)
+
+// Sanction is the penalty an uploader's strikes currently carry.
+type Sanction string
+
+const (
+	SanctionNone       Sanction = "none"
+	SanctionWarning    Sanction = "warning"
+	SanctionRestricted Sanction = "upload_restricted"
+	SanctionTerminated Sanction = "terminated"
+)
+
+// StrikePolicy is the repeat-infringer rule.
+type StrikePolicy struct {
+	// RestrictAt is the number of active strikes that restricts uploads
+	// for Restriction; TerminateAt the number that terminates the uploader.
+	RestrictAt  int           `json:"restrict_at"`
+	TerminateAt int           `json:"terminate_at"`
+	Restriction time.Duration `json:"restriction"`
+
+	// ExpiryMonths is how long a strike stays active.
+	ExpiryMonths int `json:"expiry_months"`
+}
+
+// DefaultStrikePolicy returns the three-strike rule: a warning, then a
+// 7-day upload restriction, then termination, with strikes expiring after
+// 12 months.
+func DefaultStrikePolicy() StrikePolicy {
+	return StrikePolicy{
+		RestrictAt:   2,
+		TerminateAt:  3,
+		Restriction:  7 * 24 * time.Hour,
+		ExpiryMonths: 12,
+	}
+}
+
+// Strike is one upheld infringement against an uploader. ID is the
+// decision it came from: the flag ID for a reviewed flag, the notice ID
+// for a DMCA takedown.
+type Strike struct {
+	ID         string    `json:"id"`
+	Uploader   string    `json:"uploader"`
+	ContentID  string    `json:"content_id"`
+	Reason     string    `json:"reason"`
+	Terminal   bool      `json:"terminal,omitempty"` // terminates regardless of count, e.g. CSAM
+	IssuedAt   time.Time `json:"issued_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Reversed   bool      `json:"reversed,omitempty"`
+	ReversedAt time.Time `json:"reversed_at,omitempty"`
+}
+
+// UploaderStatus is an uploader's standing under the strike policy.
+type UploaderStatus struct {
+	Uploader        string    `json:"uploader"`
+	ActiveStrikes   int       `json:"active_strikes"`
+	Sanction        Sanction  `json:"sanction"`
+	RestrictedUntil time.Time `json:"restricted_until,omitempty"`
+}
+
+// UploaderRegistry is the in-memory StrikeLedger. Sanctions are derived
+// from strike history, so reversing a strike also lifts the restriction or
+// termination it caused; termination otherwise outlasts the strikes that
+// led to it. Issued and reversed strikes are written to the AuditLog with
+// the strike ID as FlagID.
+type UploaderRegistry struct {
+	policy StrikePolicy
+	audit  AuditLog
+
+	mu      sync.Mutex
+	strikes map[string]*Strike   // by strike ID
+	byUser  map[string][]*Strike // by uploader, in issue order
+	now     func() time.Time
+}
+
+// NewUploaderRegistry creates a registry enforcing policy. audit may be
+// nil.
+func NewUploaderRegistry(policy StrikePolicy, audit AuditLog) *UploaderRegistry {
+	return &UploaderRegistry{
+		policy:  policy,
+		audit:   audit,
+		strikes: make(map[string]*Strike),
+		byUser:  make(map[string][]*Strike),
+		now:     time.Now,
+	}
+}
+
+// SetClock replaces the registry's time source. Intended for tests.
+func (r *UploaderRegistry) SetClock(now func() time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.now = now
+}
+
+// AddStrike issues s. IssuedAt defaults to now and ExpiresAt is set from
+// the policy. It returns the uploader's resulting status. An audit failure
+// is returned after the strike has been issued.
+func (r *UploaderRegistry) AddStrike(s Strike) (UploaderStatus, error) {
+	if s.ID == "" || s.Uploader == "" {
+		return UploaderStatus{}, fmt.Errorf("moderation: strike needs an ID and uploader")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, dup := r.strikes[s.ID]; dup {
+		return UploaderStatus{}, fmt.Errorf("%w: %s", ErrStrikeExists, s.ID)
+	}
+	now := r.now()
+	if s.IssuedAt.IsZero() {
+		s.IssuedAt = now
+	}
+	s.ExpiresAt = s.IssuedAt.AddDate(0, r.policy.ExpiryMonths, 0)
+	s.Reversed, s.ReversedAt = false, time.Time{}
+
+	stored := &s
+	r.strikes[s.ID] = stored
+	list := append(r.byUser[s.Uploader], stored)
+	sort.SliceStable(list, func(i, j int) bool { return list[i].IssuedAt.Before(list[j].IssuedAt) })
+	r.byUser[s.Uploader] = list
+
+	st := r.statusLocked(s.Uploader, now)
+	return st, r.record(s.ID, s.ContentID, ActionStrike, fmt.Sprintf("%s: %s", st.Sanction, s.Reason), now)
+}
+
+// ReverseStrike withdraws a strike, e.g. after a successful appeal or
+// counter-notice. Reversing an already reversed strike is a no-op. An
+// audit failure is returned after the strike has been reversed.
+func (r *UploaderRegistry) ReverseStrike(strikeID, reason string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.strikes[strikeID]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrStrikeNotFound, strikeID)
+	}
+	if s.Reversed {
+		return nil
+	}
+	now := r.now()
+	s.Reversed, s.ReversedAt = true, now
+	return r.record(s.ID, s.ContentID, ActionStrikeReversed, reason, now)
+}
+
+// Status returns the uploader's current standing.
+func (r *UploaderRegistry) Status(uploader string) UploaderStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.statusLocked(uploader, r.now())
+}
+
+// Strikes returns copies of all strikes against uploader, including
+// expired and reversed ones, oldest first.
+func (r *UploaderRegistry) Strikes(uploader string) []Strike {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := r.byUser[uploader]
+	out := make([]Strike, len(list))
+	for i, s := range list {
+		out[i] = *s
+	}
+	return out
+}
+
+// CanUpload returns nil if uploader may upload now, or an error matching
+// ErrUploadRestricted or ErrUploaderTerminated.
+func (r *UploaderRegistry) CanUpload(uploader string) error {
+	st := r.Status(uploader)
+	switch st.Sanction {
+	case SanctionTerminated:
+		return fmt.Errorf("%w: %s", ErrUploaderTerminated, uploader)
+	case SanctionRestricted:
+		return fmt.Errorf("%w: %s until %s", ErrUploadRestricted, uploader, st.RestrictedUntil.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// statusLocked replays the uploader's unreversed strikes in issue order:
+// each strike's sanction is set by how many strikes were active when it
+// was issued. Caller must hold r.mu.
+func (r *UploaderRegistry) statusLocked(uploader string, now time.Time) UploaderStatus {
+	st := UploaderStatus{Uploader: uploader, Sanction: SanctionNone}
+	var live []*Strike
+	terminated := false
+	for _, s := range r.byUser[uploader] {
+		if s.Reversed {
+			continue
+		}
+		n := 1
+		for _, prev := range live {
+			if prev.ExpiresAt.After(s.IssuedAt) {
+				n++
+			}
+		}
+		live = append(live, s)
+		switch {
+		case s.Terminal || n >= r.policy.TerminateAt:
+			terminated = true
+		case n >= r.policy.RestrictAt:
+			st.RestrictedUntil = s.IssuedAt.Add(r.policy.Restriction)
+		}
+	}
+	for _, s := range live {
+		if s.ExpiresAt.After(now) {
+			st.ActiveStrikes++
+		}
+	}
+
+	switch {
+	case terminated:
+		st.Sanction = SanctionTerminated
+	case now.Before(st.RestrictedUntil):
+		st.Sanction = SanctionRestricted
+	case st.ActiveStrikes > 0:
+		st.Sanction = SanctionWarning
+	}
+	if st.Sanction != SanctionRestricted {
+		st.RestrictedUntil = time.Time{}
+	}
+	return st
+}
+
+// record appends an audit record for a strike. Caller must hold r.mu.
+func (r *UploaderRegistry) record(strikeID, contentID string, action ReviewAction, reason string, at time.Time) error {
+	if r.audit == nil {
+		return nil
+	}
+	err := r.audit.Append(AuditRecord{
+		ID:        fmt.Sprintf("audit-%s-%s", strikeID, action),
+		FlagID:    strikeID,
+		ContentID: contentID,
+		Action:    action,
+		ActionBy:  "system",
+		Reason:    reason,
+		Timestamp: at,
+	})
+	if err != nil {
+		return fmt.Errorf("moderation: audit %s %s: %w", strikeID, action, err)
+	}
+	return nil
+}
+
+var _ StrikeLedger = (*UploaderRegistry)(nil)
@@ -0,0 +1,138 @@
+package moderation
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestRegistry() (*UploaderRegistry, *testClock) {
+	clock := &testClock{t: time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)}
+	r := NewUploaderRegistry(DefaultStrikePolicy(), NewMockAuditLog())
+	r.SetClock(clock.Now)
+	return r, clock
+}
+
+func TestUploaderRegistry_ThreeStrikes(t *testing.T) {
+	r, clock := newTestRegistry()
+
+	steps := []struct {
+		strike  string
+		advance time.Duration
+		want    Sanction
+		err     error
+	}{
+		{"s1", 0, SanctionWarning, nil},
+		{"s2", 24 * time.Hour, SanctionRestricted, ErrUploadRestricted},
+		{"", 7 * 24 * time.Hour, SanctionWarning, nil}, // restriction lapses
+		{"s3", 24 * time.Hour, SanctionTerminated, ErrUploaderTerminated},
+		{"", 400 * 24 * time.Hour, SanctionTerminated, ErrUploaderTerminated}, // outlasts expiry
+	}
+	for _, step := range steps {
+		clock.Advance(step.advance)
+		if step.strike != "" {
+			if _, err := r.AddStrike(Strike{ID: step.strike, Uploader: "alice", ContentID: "vid-" + step.strike}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if got := r.Status("alice").Sanction; got != step.want {
+			t.Fatalf("after %q: sanction %s, want %s", step.strike, got, step.want)
+		}
+		if err := r.CanUpload("alice"); !errors.Is(err, step.err) {
+			t.Fatalf("after %q: CanUpload %v, want %v", step.strike, err, step.err)
+		}
+	}
+	if _, err := r.AddStrike(Strike{ID: "s1", Uploader: "alice"}); !errors.Is(err, ErrStrikeExists) {
+		t.Fatalf("expected ErrStrikeExists, got %v", err)
+	}
+}
+
+func TestUploaderRegistry_StrikesExpire(t *testing.T) {
+	r, clock := newTestRegistry()
+	r.AddStrike(Strike{ID: "s1", Uploader: "bob"})
+	clock.Advance(366 * 24 * time.Hour)
+
+	if st := r.Status("bob"); st.ActiveStrikes != 0 || st.Sanction != SanctionNone {
+		t.Fatalf("expected strike expired, got %+v", st)
+	}
+	// An expired strike doesn't count toward the next one.
+	if st, _ := r.AddStrike(Strike{ID: "s2", Uploader: "bob"}); st.Sanction != SanctionWarning || st.ActiveStrikes != 1 {
+		t.Fatalf("expected a fresh warning, got %+v", st)
+	}
+}
+
+func TestUploaderRegistry_ReverseLiftsSanction(t *testing.T) {
+	r, _ := newTestRegistry()
+	for _, id := range []string{"s1", "s2", "s3"} {
+		r.AddStrike(Strike{ID: id, Uploader: "carol"})
+	}
+	if err := r.ReverseStrike("s2", "appeal upheld"); err != nil {
+		t.Fatal(err)
+	}
+	if st := r.Status("carol"); st.Sanction != SanctionRestricted || st.ActiveStrikes != 2 {
+		t.Fatalf("expected termination lifted back to restriction, got %+v", st)
+	}
+	if err := r.ReverseStrike("nope", ""); !errors.Is(err, ErrStrikeNotFound) {
+		t.Fatalf("expected ErrStrikeNotFound, got %v", err)
+	}
+	if got := r.Strikes("carol"); len(got) != 3 || !got[1].Reversed {
+		t.Fatalf("expected history kept with s2 reversed, got %+v", got)
+	}
+}
+
+func TestModerationQueue_DenyIssuesStrike(t *testing.T) {
+	r, _ := newTestRegistry()
+	q := NewMockModerationQueue(NewMockDenyList(), nil, DefaultEscalationConfig())
+	q.SetStrikeLedger(r)
+
+	q.Submit(ContentFlag{ID: "f1", ContentID: "vid-1", Uploader: "dave", Category: CategoryAbuse})
+	q.Submit(ContentFlag{ID: "f2", ContentID: "vid-2", Uploader: "dave", Category: CategoryAbuse})
+	q.Review("f1", ActionDeny, "mod-1")
+	q.Review("f2", ActionDismiss, "mod-1")
+
+	if st := r.Status("dave"); st.ActiveStrikes != 1 {
+		t.Fatalf("expected one strike from the deny, got %+v", st)
+	}
+}
+
+func TestModerationQueue_DenyReturnsErrors(t *testing.T) {
+	r := NewUploaderRegistry(DefaultStrikePolicy(), failingAuditLog{NewMockAuditLog()})
+	q := NewMockModerationQueue(failingDenyList{NewMockDenyList()}, failingAuditLog{NewMockAuditLog()}, DefaultEscalationConfig())
+	q.SetStrikeLedger(r)
+
+	q.Submit(ContentFlag{ID: "f1", ContentID: "vid-1", Uploader: "dave", Category: CategoryAbuse})
+	err := q.Review("f1", ActionDeny, "mod-1")
+	for _, want := range []string{"deny vid-1: denylist unavailable", "strike dave: moderation: audit f1 strike", "audit f1: disk full"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %q in %v", want, err)
+		}
+	}
+	if st := r.Status("dave"); st.ActiveStrikes != 1 {
+		t.Fatalf("expected the strike issued despite its audit failure, got %+v", st)
+	}
+	if err := r.ReverseStrike("f1", "test"); err == nil {
+		t.Fatal("expected reversal audit failure returned")
+	}
+}
+
+func TestDMCAService_StrikeReversedOnRestore(t *testing.T) {
+	s, _, _, clock := newTestDMCA()
+	r := NewUploaderRegistry(DefaultStrikePolicy(), nil)
+	r.SetClock(clock.Now)
+	s.SetStrikeLedger(r)
+
+	notice := validNotice("vid-1")
+	notice.Uploader = "erin"
+	n, _ := s.SubmitNotice(notice)
+	if st := r.Status("erin"); st.Sanction != SanctionWarning {
+		t.Fatalf("expected warning after takedown, got %+v", st)
+	}
+
+	s.SubmitCounterNotice(validCounter(n.ID))
+	clock.Advance(30 * 24 * time.Hour)
+	s.RestoreDue()
+	if st := r.Status("erin"); st.ActiveStrikes != 0 || st.Sanction != SanctionNone {
+		t.Fatalf("expected strike reversed on restore, got %+v", st)
+	}
+}
@@ -0,0 +1,141 @@
+package policy
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// CircuitState is the per-node circuit breaker state.
+//
+//	closed    → healthy, full traffic
+//	open      → tripped after more than ProofGraceMisses failures; no traffic
+//	            until HalfOpenProbeInterval has elapsed
+//	half-open → a probe is in flight; success closes, failure re-opens
+type CircuitState int
+
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// DueForProbe reports whether a probe should be sent to nodeID now: its
+// circuit is open (or a previous probe went unanswered) and at least
+// HalfOpenProbeInterval has passed since it opened or was last probed.
+// A true result claims the probe: the node moves to half-open and its probe
+// timer restarts, so concurrent callers do not probe the same node twice.
+func (e *Engine) DueForProbe(nodeID string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	ns, ok := e.nodes[nodeID]
+	if !ok {
+		return false
+	}
+	return e.claimProbe(ns, e.now())
+}
+
+// DueProbes claims a probe for every node that is due and returns their IDs
+// in sorted order.
+func (e *Engine) DueProbes() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := e.now()
+	var due []string
+	for id, ns := range e.nodes {
+		if e.claimProbe(ns, now) {
+			due = append(due, id)
+		}
+	}
+	sort.Strings(due)
+	return due
+}
+
+// Probes checks for due probes every tick and delivers node IDs on the
+// returned channel until ctx is cancelled. Each delivered node must be
+// answered with RecordProbeResult. health.Monitor already probes the nodes
+// it polls; use Probes only for nodes it does not.
+func (e *Engine) Probes(ctx context.Context, tick time.Duration) <-chan string {
+	out := make(chan string)
+	go func() {
+		defer close(out)
+		t := time.NewTicker(tick)
+		defer t.Stop()
+		for {
+			for _, id := range e.DueProbes() {
+				select {
+				case out <- id:
+				case <-ctx.Done():
+					return
+				}
+			}
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// RecordProbeResult records the outcome of a probe. Success closes the
+// circuit and clears missed proofs and integrity failures; failure re-opens it and restarts the
+// probe timer.
+func (e *Engine) RecordProbeResult(nodeID string, ok bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	ns := e.getOrCreate(nodeID)
+	if ns == nil {
+		return
+	}
+	if ok {
+		ns.circuit = CircuitClosed
+		ns.missedProofs = 0
+		ns.integrityFailures = 0
+		return
+	}
+	ns.circuit = CircuitOpen
+	ns.lastProbe = e.now()
+}
+
+// tripIfExceeded opens the circuit once missed proofs and integrity
+// failures exceed the grace budget. A failure while half-open re-opens and restarts the timer.
+// Caller must hold e.mu.
+func (e *Engine) tripIfExceeded(ns *nodeState) {
+	if ns.failures() <= e.config.ProofGraceMisses {
+		return
+	}
+	if ns.circuit != CircuitOpen {
+		ns.circuit = CircuitOpen
+		ns.lastProbe = e.now()
+	}
+}
+
+// claimProbe implements DueForProbe for one node. Caller must hold e.mu.
+func (e *Engine) claimProbe(ns *nodeState, now time.Time) bool {
+	if ns.circuit == CircuitClosed {
+		return false
+	}
+	if now.Sub(ns.lastProbe) < e.config.HalfOpenProbeInterval {
+		return false
+	}
+	ns.circuit = CircuitHalfOpen
+	ns.lastProbe = now
+	return true
+}
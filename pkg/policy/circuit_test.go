@@ -0,0 +1,149 @@
+package policy
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced time source.
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) Now() time.Time          { return c.t }
+func (c *fakeClock) Advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestEngine() (*Engine, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	e := NewEngine(DefaultConfig())
+	e.SetClock(clock.Now)
+	return e, clock
+}
+
+func trip(e *Engine, nodeID string) {
+	for i := 0; i <= e.config.ProofGraceMisses; i++ {
+		e.RecordProofResult(nodeID, false)
+	}
+}
+
+func TestCircuit_TripAndProbeInterval(t *testing.T) {
+	e, clock := newTestEngine()
+
+	e.RecordProofResult("node-1", false)
+	e.RecordProofResult("node-1", false)
+	if s := e.Score("node-1", ""); s.Circuit != CircuitClosed {
+		t.Fatalf("expected closed within grace, got %s", s.Circuit)
+	}
+
+	e.RecordProofResult("node-1", false)
+	if s := e.Score("node-1", ""); s.Circuit != CircuitOpen || !s.HalfOpen {
+		t.Fatalf("expected open after grace exceeded, got %s", s.Circuit)
+	}
+
+	if e.DueForProbe("node-1") {
+		t.Fatal("probe should not be due before HalfOpenProbeInterval")
+	}
+	clock.Advance(5 * time.Minute)
+	if !e.DueForProbe("node-1") {
+		t.Fatal("probe should be due after HalfOpenProbeInterval")
+	}
+	if s := e.Score("node-1", ""); s.Circuit != CircuitHalfOpen {
+		t.Fatalf("expected half-open after claiming probe, got %s", s.Circuit)
+	}
+	if e.DueForProbe("node-1") {
+		t.Fatal("a claimed probe must not be handed out twice")
+	}
+}
+
+func TestCircuit_ProbeSuccessCloses(t *testing.T) {
+	e, clock := newTestEngine()
+	trip(e, "node-1")
+	clock.Advance(5 * time.Minute)
+	e.DueForProbe("node-1")
+
+	e.RecordProbeResult("node-1", true)
+
+	s := e.Score("node-1", "")
+	if s.Circuit != CircuitClosed || s.HalfOpen || s.MissedProofs != 0 {
+		t.Fatalf("expected closed circuit after successful probe, got %+v", s)
+	}
+}
+
+func TestCircuit_PassingProofKeepsIntegrityFailures(t *testing.T) {
+	e, clock := newTestEngine()
+	for i := 0; i <= e.config.ProofGraceMisses; i++ {
+		e.RecordIntegrityFailure("node-1")
+	}
+	e.RecordProofResult("node-1", true)
+
+	s := e.Score("node-1", "")
+	if s.Circuit != CircuitOpen || s.IntegrityFailures != 3 || s.MissedProofs != 0 {
+		t.Fatalf("expected a passing proof not to clear integrity failures, got %+v", s)
+	}
+
+	clock.Advance(5 * time.Minute)
+	e.DueForProbe("node-1")
+	e.RecordProbeResult("node-1", true)
+	if s := e.Score("node-1", ""); s.Circuit != CircuitClosed || s.IntegrityFailures != 0 {
+		t.Fatalf("expected a successful probe to clear integrity failures, got %+v", s)
+	}
+}
+
+func TestCircuit_ProbeFailureResetsTimer(t *testing.T) {
+	e, clock := newTestEngine()
+	trip(e, "node-1")
+	clock.Advance(5 * time.Minute)
+	e.DueForProbe("node-1")
+
+	clock.Advance(time.Minute)
+	e.RecordProbeResult("node-1", false)
+	if s := e.Score("node-1", ""); s.Circuit != CircuitOpen {
+		t.Fatalf("expected re-opened circuit, got %s", s.Circuit)
+	}
+
+	clock.Advance(4 * time.Minute)
+	if e.DueForProbe("node-1") {
+		t.Fatal("timer should restart from the failed probe")
+	}
+	clock.Advance(time.Minute)
+	if !e.DueForProbe("node-1") {
+		t.Fatal("probe should be due one interval after the failed probe")
+	}
+}
+
+func TestCircuit_UnansweredProbeRetried(t *testing.T) {
+	e, clock := newTestEngine()
+	trip(e, "node-1")
+	clock.Advance(5 * time.Minute)
+	e.DueForProbe("node-1")
+
+	clock.Advance(5 * time.Minute)
+	if !e.DueForProbe("node-1") {
+		t.Fatal("an unanswered probe should be re-issued after another interval")
+	}
+}
+
+func TestCircuit_DueProbesAndChannel(t *testing.T) {
+	e, clock := newTestEngine()
+	trip(e, "node-b")
+	trip(e, "node-a")
+	e.RecordLatency("node-healthy", time.Millisecond)
+	clock.Advance(5 * time.Minute)
+
+	due := e.DueProbes()
+	if len(due) != 2 || due[0] != "node-a" || due[1] != "node-b" {
+		t.Fatalf("expected [node-a node-b], got %v", due)
+	}
+
+	e.RecordProbeResult("node-a", false)
+	clock.Advance(5 * time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	probes := e.Probes(ctx, time.Millisecond)
+	got := map[string]bool{<-probes: true, <-probes: true}
+	if !got["node-a"] || !got["node-b"] {
+		t.Fatalf("expected probes for node-a and node-b, got %v", got)
+	}
+}
@@ -0,0 +1,421 @@
+package policy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidConfig is matched by every error returned from Config.Validate.
+var ErrInvalidConfig = errors.New("policy: invalid config")
+
+// FieldError describes one invalid Config field.
+type FieldError struct {
+	Field   string
+	Problem string
+}
+
+func (e FieldError) Error() string { return e.Field + ": " + e.Problem }
+
+// ConfigError lists every invalid field found by Config.Validate.
+type ConfigError struct {
+	Fields []FieldError
+}
+
+func (e *ConfigError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Error()
+	}
+	return "policy: invalid config: " + strings.Join(msgs, "; ")
+}
+
+func (e *ConfigError) Unwrap() error { return ErrInvalidConfig }
+
+// Validate checks every field and reports all problems at once as a
+// *ConfigError, or returns nil.
+func (cfg Config) Validate() error {
+	var errs []FieldError
+	bad := func(field, format string, args ...any) {
+		errs = append(errs, FieldError{Field: field, Problem: fmt.Sprintf(format, args...)})
+	}
+	unit := func(field string, v float64) {
+		if v < 0 || v > 1 {
+			bad(field, "must be between 0 and 1, got %v", v)
+		}
+	}
+
+	unit("LatencyWeight", cfg.LatencyWeight)
+	unit("ThroughputWeight", cfg.ThroughputWeight)
+	unit("ErrorRateWeight", cfg.ErrorRateWeight)
+	if cfg.GeoBoost < 0 {
+		bad("GeoBoost", "must not be negative, got %v", cfg.GeoBoost)
+	}
+	for i, b := range cfg.GeoTierBoosts {
+		if b < 0 || b > cfg.GeoBoost {
+			bad(fmt.Sprintf("GeoTierBoosts[%d]", i), "must be between 0 and GeoBoost (%v), got %v", cfg.GeoBoost, b)
+		}
+	}
+	if len(cfg.GeoDistances) > 0 && cfg.GeoDistanceCap <= 0 {
+		bad("GeoDistanceCap", "must be positive when GeoDistances is set, got %v", cfg.GeoDistanceCap)
+	}
+	for a, row := range cfg.GeoDistances {
+		for b, d := range row {
+			if d < 0 {
+				bad(fmt.Sprintf("GeoDistances[%s][%s]", a, b), "must not be negative, got %v", d)
+			}
+		}
+	}
+	if cfg.MinSamples < 1 {
+		bad("MinSamples", "must be at least 1, got %d", cfg.MinSamples)
+	}
+	if cfg.ProofGraceMisses < 0 {
+		bad("ProofGraceMisses", "must not be negative, got %d", cfg.ProofGraceMisses)
+	}
+	if cfg.ProofTTL <= 0 {
+		bad("ProofTTL", "must be positive, got %v", cfg.ProofTTL)
+	}
+	if cfg.HalfOpenProbeInterval <= 0 {
+		bad("HalfOpenProbeInterval", "must be positive, got %v", cfg.HalfOpenProbeInterval)
+	}
+	if cfg.LatencyMaxAge < 0 {
+		bad("LatencyMaxAge", "must not be negative, got %v", cfg.LatencyMaxAge)
+	}
+	switch cfg.LatencySignal {
+	case "", SignalP95:
+	case SignalEWMA:
+		if cfg.EWMAHalfLife <= 0 {
+			bad("EWMAHalfLife", "must be positive with the ewma signal, got %v", cfg.EWMAHalfLife)
+		}
+	default:
+		bad("LatencySignal", "unknown signal %q", cfg.LatencySignal)
+	}
+	if cfg.EWMAHalfLife < 0 {
+		bad("EWMAHalfLife", "must not be negative, got %v", cfg.EWMAHalfLife)
+	}
+	switch cfg.QuantileBackend {
+	case "", QuantileExact, QuantileHistogram:
+	default:
+		bad("QuantileBackend", "unknown backend %q", cfg.QuantileBackend)
+	}
+	if cfg.NodeIdleTTL < 0 {
+		bad("NodeIdleTTL", "must not be negative, got %v", cfg.NodeIdleTTL)
+	}
+	if cfg.TombstoneTTL < 0 {
+		bad("TombstoneTTL", "must not be negative, got %v", cfg.TombstoneTTL)
+	}
+	if cfg.ThroughputWeight > 0 && cfg.TargetThroughput <= 0 {
+		bad("TargetThroughput", "must be positive when ThroughputWeight is set, got %v", cfg.TargetThroughput)
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return &ConfigError{Fields: errs}
+}
+
+// Config returns a copy of the engine's current config.
+func (e *Engine) Config() Config {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.config
+}
+
+// UpdateConfig validates cfg and swaps it in atomically, keeping all node
+// state. If ProofGraceMisses changed, circuits are re-evaluated against
+// it: closed nodes now over budget trip and open nodes now within budget
+// close, while half-open nodes are left to their pending probe. If the
+// latency estimator settings changed (LatencyMaxAge, the QuantileBackend in
+// effect, or a different NewEstimator function), exact-backend windows are
+// replayed into new estimators; other estimators are kept as they are and
+// the new settings apply to nodes seen from now on.
+func (e *Engine) UpdateConfig(cfg Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	cfg.GeoDistances = cfg.GeoDistances.normalized()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	old := e.config
+	e.config = cfg
+	rebuild := old.LatencyMaxAge != cfg.LatencyMaxAge ||
+		!sameFunc(old.NewEstimator, cfg.NewEstimator) ||
+		(cfg.NewEstimator == nil && old.QuantileBackend != cfg.QuantileBackend)
+
+	regrace := old.ProofGraceMisses != cfg.ProofGraceMisses
+	for _, ns := range e.nodes {
+		// A half-open node has a probe in flight; its result decides.
+		if regrace && ns.circuit != CircuitHalfOpen {
+			if ns.failures() > cfg.ProofGraceMisses {
+				e.tripIfExceeded(ns)
+			} else {
+				ns.circuit = CircuitClosed
+			}
+		}
+		if x, ok := ns.latency.(*ExactEstimator); ok && rebuild {
+			est := e.newEstimator()
+			for _, s := range x.latencySamples() {
+				est.Add(s.d, s.at)
+			}
+			ns.latency = est
+		}
+	}
+	return nil
+}
+
+// sameFunc reports whether a and b are both nil or the same function.
+// Closures of one function literal compare equal whatever they capture.
+func sameFunc(a, b func() QuantileEstimator) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return reflect.ValueOf(a).Pointer() == reflect.ValueOf(b).Pointer()
+}
+
+// LoadConfig reads a config file and overlays it on DefaultConfig. Files
+// ending in .json are JSON objects; anything else is read as flat
+// "key: value" or "key = value" lines with # comments, so simple YAML and
+// TOML files work. Keys are the snake_case field names (latency_weight,
+// proof_ttl, ...); durations use time.ParseDuration syntax ("30m"); lists
+// are comma-separated; geo distances are written as
+// geo_distances.<from>.<to>. Unknown keys are an error, and the result is
+// validated.
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("policy: read config: %w", err)
+	}
+	var kv map[string]string
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		kv, err = flattenJSON(data)
+	} else {
+		kv, err = parseKeyValues(data)
+	}
+	if err != nil {
+		return Config{}, fmt.Errorf("policy: parse %s: %w", path, err)
+	}
+
+	cfg := DefaultConfig()
+	keys := make([]string, 0, len(kv))
+	for k := range kv {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := cfg.set(k, kv[k]); err != nil {
+			return Config{}, fmt.Errorf("policy: %s: %s: %w", path, k, err)
+		}
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// set assigns one config key from its string form.
+func (cfg *Config) set(key, val string) error {
+	if rest, ok := strings.CutPrefix(key, "geo_distances."); ok {
+		from, to, ok := strings.Cut(rest, ".")
+		if !ok {
+			return errors.New("want geo_distances.<from>.<to>")
+		}
+		d, err := time.ParseDuration(val)
+		if err != nil {
+			return err
+		}
+		if cfg.GeoDistances == nil {
+			cfg.GeoDistances = GeoMatrix{}
+		}
+		from, to = geoKey(from), geoKey(to)
+		if cfg.GeoDistances[from] == nil {
+			cfg.GeoDistances[from] = map[string]time.Duration{}
+		}
+		cfg.GeoDistances[from][to] = d
+		return nil
+	}
+
+	var err error
+	float := func(p *float64) { *p, err = strconv.ParseFloat(val, 64) }
+	integer := func(p *int) { *p, err = strconv.Atoi(val) }
+	duration := func(p *time.Duration) { *p, err = time.ParseDuration(val) }
+
+	switch key {
+	case "latency_weight":
+		float(&cfg.LatencyWeight)
+	case "geo_boost":
+		float(&cfg.GeoBoost)
+	case "geo_tier_boosts":
+		cfg.GeoTierBoosts = nil
+		for _, f := range strings.Split(val, ",") {
+			if f = strings.TrimSpace(f); f == "" {
+				continue
+			}
+			var b float64
+			if b, err = strconv.ParseFloat(f, 64); err != nil {
+				return err
+			}
+			cfg.GeoTierBoosts = append(cfg.GeoTierBoosts, b)
+		}
+	case "geo_distance_cap":
+		duration(&cfg.GeoDistanceCap)
+	case "min_samples":
+		integer(&cfg.MinSamples)
+	case "proof_grace_misses":
+		integer(&cfg.ProofGraceMisses)
+	case "proof_ttl":
+		duration(&cfg.ProofTTL)
+	case "half_open_probe_interval":
+		duration(&cfg.HalfOpenProbeInterval)
+	case "latency_max_age":
+		duration(&cfg.LatencyMaxAge)
+	case "latency_signal":
+		cfg.LatencySignal = LatencySignal(val)
+	case "ewma_half_life":
+		duration(&cfg.EWMAHalfLife)
+	case "quantile_backend":
+		cfg.QuantileBackend = QuantileBackend(val)
+	case "throughput_weight":
+		float(&cfg.ThroughputWeight)
+	case "target_throughput":
+		float(&cfg.TargetThroughput)
+	case "error_rate_weight":
+		float(&cfg.ErrorRateWeight)
+	case "node_idle_ttl":
+		duration(&cfg.NodeIdleTTL)
+	case "tombstone_ttl":
+		duration(&cfg.TombstoneTTL)
+	case "require_registration":
+		cfg.RequireRegistration, err = strconv.ParseBool(val)
+	default:
+		return errors.New("unknown key")
+	}
+	return err
+}
+
+// parseKeyValues reads flat "key: value" / "key = value" lines. Values may
+// be quoted; list values may be wrapped in brackets.
+func parseKeyValues(data []byte) (map[string]string, error) {
+	kv := make(map[string]string)
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if line == "" || line == "---" {
+			continue
+		}
+		i := strings.IndexAny(line, ":=")
+		if i < 0 {
+			return nil, fmt.Errorf("line %d: want key: value or key = value", n)
+		}
+		key := strings.TrimSpace(line[:i])
+		val := strings.TrimSpace(line[i+1:])
+		val = strings.Trim(val, `"'`)
+		val = strings.TrimSuffix(strings.TrimPrefix(val, "["), "]")
+		kv[key] = val
+	}
+	return kv, sc.Err()
+}
+
+// flattenJSON turns a JSON object into dotted keys and string values:
+// nested objects become "a.b" keys and arrays comma-separated values.
+func flattenJSON(data []byte) (map[string]string, error) {
+	var root map[string]any
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	kv := make(map[string]string)
+	var walk func(prefix string, v any) error
+	walk = func(prefix string, v any) error {
+		switch v := v.(type) {
+		case map[string]any:
+			for k, child := range v {
+				if err := walk(prefix+k+".", child); err != nil {
+					return err
+				}
+			}
+			return nil
+		case []any:
+			parts := make([]string, len(v))
+			for i, el := range v {
+				parts[i] = jsonScalar(el)
+			}
+			kv[strings.TrimSuffix(prefix, ".")] = strings.Join(parts, ",")
+		case string, float64, bool:
+			kv[strings.TrimSuffix(prefix, ".")] = jsonScalar(v)
+		default:
+			return fmt.Errorf("%s: unsupported value %v", strings.TrimSuffix(prefix, "."), v)
+		}
+		return nil
+	}
+	if err := walk("", root); err != nil {
+		return nil, err
+	}
+	return kv, nil
+}
+
+// jsonScalar formats a decoded JSON value for the key-value parser.
+// Numbers are written without an exponent so integer fields like 1000000
+// still parse with Atoi.
+func jsonScalar(v any) string {
+	if f, ok := v.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// RunConfigReloader polls path every interval and applies it with
+// UpdateConfig whenever its modification time changes, until ctx is
+// cancelled. The current Scorer and NewEstimator are kept, since they
+// can't be expressed in a file. Load and validation errors are passed to
+// onError (if not nil) and leave the running config in place.
+func (e *Engine) RunConfigReloader(ctx context.Context, path string, interval time.Duration, onError func(error)) error {
+	var lastMod time.Time
+	if fi, err := os.Stat(path); err == nil {
+		lastMod = fi.ModTime()
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			fi, err := os.Stat(path)
+			if err != nil {
+				if onError != nil {
+					onError(fmt.Errorf("policy: stat config: %w", err))
+				}
+				continue
+			}
+			if fi.ModTime().Equal(lastMod) {
+				continue
+			}
+			lastMod = fi.ModTime()
+
+			cfg, err := LoadConfig(path)
+			if err == nil {
+				cur := e.Config()
+				cfg.Scorer, cfg.NewEstimator = cur.Scorer, cur.NewEstimator
+				err = e.UpdateConfig(cfg)
+			}
+			if err != nil && onError != nil {
+				onError(err)
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
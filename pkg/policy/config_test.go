@@ -0,0 +1,250 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfig_DefaultIsValid(t *testing.T) {
+	if err := DefaultConfig().Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConfig_ValidateReportsEveryField(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.LatencyWeight = -0.1
+	cfg.MinSamples = 0
+	cfg.ProofTTL = 0
+	cfg.QuantileBackend = "tdigest"
+
+	err := cfg.Validate()
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("expected ErrInvalidConfig, got %v", err)
+	}
+	var ce *ConfigError
+	if !errors.As(err, &ce) || len(ce.Fields) != 4 {
+		t.Fatalf("expected 4 field errors, got %v", err)
+	}
+	for _, f := range []string{"LatencyWeight", "MinSamples", "ProofTTL", "QuantileBackend"} {
+		if !strings.Contains(err.Error(), f+":") {
+			t.Errorf("error does not mention %s: %v", f, err)
+		}
+	}
+}
+
+func TestConfig_LoadKeyValue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	os.WriteFile(path, []byte(`
+# policy overrides
+latency_weight: 0.6
+min_samples: 5
+proof_ttl: "12h"
+geo_tier_boosts: [0.02, 0.05]
+geo_distances.na/us.eu: 90ms
+geo_distances.us-west.ap-south: 150ms
+`), 0o644)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.LatencyWeight != 0.6 || cfg.MinSamples != 5 || cfg.ProofTTL != 12*time.Hour {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+	if len(cfg.GeoTierBoosts) != 2 || cfg.GeoTierBoosts[1] != 0.05 {
+		t.Fatalf("unexpected tier boosts %v", cfg.GeoTierBoosts)
+	}
+	if d, _ := cfg.GeoDistances.Distance("us-east", "eu-west"); d != 90*time.Millisecond {
+		t.Fatalf("unexpected geo distance %v", d)
+	}
+	if d, _ := cfg.GeoDistances.Distance("us-west-2", "ap-south"); d != 150*time.Millisecond {
+		t.Fatalf("expected dash-form keys to match, got %v", d)
+	}
+	if cfg.GeoBoost != DefaultConfig().GeoBoost {
+		t.Fatal("expected unset keys to keep defaults")
+	}
+}
+
+func TestConfig_LoadJSON(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "policy.json")
+	os.WriteFile(path, []byte(`{"latency_signal": "ewma", "ewma_half_life": "30s", "geo_distances": {"eu": {"as": "150ms"}}}`), 0o644)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.LatencySignal != SignalEWMA || cfg.EWMAHalfLife != 30*time.Second {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+
+	big := filepath.Join(dir, "big.json")
+	os.WriteFile(big, []byte(`{"min_samples": 1000000, "geo_tier_boosts": [0.03, 0.06]}`), 0o644)
+	if cfg, err := LoadConfig(big); err != nil || cfg.MinSamples != 1000000 {
+		t.Fatalf("expected large integer loaded, got %d, %v", cfg.MinSamples, err)
+	}
+
+	bad := filepath.Join(dir, "bad.json")
+	os.WriteFile(bad, []byte(`{"latency_wieght": 0.5}`), 0o644)
+	if _, err := LoadConfig(bad); err == nil || !strings.Contains(err.Error(), "latency_wieght") {
+		t.Fatalf("expected unknown key error, got %v", err)
+	}
+
+	invalid := filepath.Join(dir, "invalid.toml")
+	os.WriteFile(invalid, []byte("min_samples = 0\n"), 0o644)
+	if _, err := LoadConfig(invalid); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+}
+
+func TestConfig_UpdateKeepsStateAndReevaluatesCircuits(t *testing.T) {
+	e, _ := newTestEngine()
+	seedNode(e, "node-1", 50*time.Millisecond)
+	e.RecordProofResult("node-2", false)
+	e.RecordProofResult("node-2", false)
+	trip(e, "node-3") // 3 misses
+
+	cfg := DefaultConfig()
+	cfg.ProofGraceMisses = 1
+	if err := e.UpdateConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if s := e.Score("node-1", ""); s.SampleCount != 10 {
+		t.Fatalf("expected latency window kept, got %d samples", s.SampleCount)
+	}
+	if c := e.Score("node-2", "").Circuit; c != CircuitOpen {
+		t.Fatalf("expected node-2 tripped under tighter grace, got %s", c)
+	}
+
+	cfg.ProofGraceMisses = 5
+	if err := e.UpdateConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"node-2", "node-3"} {
+		if c := e.Score(id, "").Circuit; c != CircuitClosed {
+			t.Fatalf("expected %s closed under looser grace, got %s", id, c)
+		}
+	}
+
+	cfg.MinSamples = 0
+	if err := e.UpdateConfig(cfg); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("expected invalid config rejected, got %v", err)
+	}
+	if e.Config().MinSamples != 10 {
+		t.Fatal("expected rejected config not applied")
+	}
+}
+
+func TestConfig_UpdateLeavesCircuitsWithoutGraceChange(t *testing.T) {
+	e, clock := newTestEngine()
+	trip(e, "open")
+	trip(e, "probing")
+	clock.Advance(e.Config().HalfOpenProbeInterval)
+	if !e.DueForProbe("probing") {
+		t.Fatal("expected probe claimed")
+	}
+
+	// An unrelated reload touches no circuit.
+	cfg := DefaultConfig()
+	cfg.LatencyWeight = 0.6
+	if err := e.UpdateConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if c := e.Score("open", "").Circuit; c != CircuitOpen {
+		t.Fatalf("expected open circuit kept, got %s", c)
+	}
+	if c := e.Score("probing", "").Circuit; c != CircuitHalfOpen {
+		t.Fatalf("expected half-open circuit kept, got %s", c)
+	}
+
+	// A looser grace closes the open node but not the one being probed.
+	cfg.ProofGraceMisses = 5
+	if err := e.UpdateConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if c := e.Score("open", "").Circuit; c != CircuitClosed {
+		t.Fatalf("expected open node closed under looser grace, got %s", c)
+	}
+	if c := e.Score("probing", "").Circuit; c != CircuitHalfOpen {
+		t.Fatalf("expected half-open node left to its probe, got %s", c)
+	}
+}
+
+func TestConfig_UpdateSwitchesBackend(t *testing.T) {
+	e, _ := newTestEngine()
+	seedNode(e, "node-1", 50*time.Millisecond)
+
+	cfg := DefaultConfig()
+	cfg.QuantileBackend = QuantileHistogram
+	if err := e.UpdateConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := e.nodes["node-1"].latency.(*HistogramEstimator); !ok {
+		t.Fatalf("expected histogram estimator, got %T", e.nodes["node-1"].latency)
+	}
+	if s := e.Score("node-1", ""); s.SampleCount != 10 {
+		t.Fatalf("expected samples replayed, got %d", s.SampleCount)
+	}
+}
+
+func TestConfig_UpdateKeepsCustomEstimators(t *testing.T) {
+	built := 0
+	cfg := DefaultConfig()
+	cfg.NewEstimator = func() QuantileEstimator {
+		built++
+		return NewExactEstimator(cfg.LatencyMaxAge)
+	}
+	e := NewEngine(cfg)
+	seedNode(e, "node-1", 50*time.Millisecond)
+	est := e.nodes["node-1"].latency
+
+	// A reload carries NewEstimator forward; nothing is rebuilt.
+	next := e.Config()
+	next.LatencyWeight = 0.6
+	if err := e.UpdateConfig(next); err != nil {
+		t.Fatal(err)
+	}
+	if e.nodes["node-1"].latency != est || built != 1 {
+		t.Fatalf("expected estimator kept, built %d", built)
+	}
+
+	next.NewEstimator = nil
+	next.QuantileBackend = QuantileHistogram
+	if err := e.UpdateConfig(next); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := e.nodes["node-1"].latency.(*HistogramEstimator); !ok {
+		t.Fatalf("expected estimator rebuilt when the backend changes, got %T", e.nodes["node-1"].latency)
+	}
+}
+
+func TestConfig_Reloader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.conf")
+	os.WriteFile(path, []byte("min_samples = 10\n"), 0o644)
+
+	e := NewEngine(DefaultConfig())
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- e.RunConfigReloader(ctx, path, 5*time.Millisecond, nil) }()
+
+	os.WriteFile(path, []byte("min_samples = 3\n"), 0o644)
+	// Keep bumping the mtime in case the reloader's first stat raced the
+	// write above.
+	for i := 1; e.Config().MinSamples != 3; i++ {
+		if i > 200 {
+			t.Fatal("config not reloaded")
+		}
+		os.Chtimes(path, time.Now(), time.Now().Add(time.Duration(i)*time.Second))
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
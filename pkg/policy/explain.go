@@ -0,0 +1,138 @@
+package policy
+
+import (
+	"fmt"
+	"time"
+)
+
+// Explanation breaks a node's score into its parts for operator tooling and
+// logs. It marshals to JSON.
+//
+// The typed fields (Grace through ProofPenalty) are read from components
+// named the way DefaultScorer names them; with a custom Scorer they may be
+// zero, and Components is the authoritative breakdown.
+type Explanation struct {
+	NodeID string  `json:"node_id"`
+	Known  bool    `json:"known"`
+	Score  float64 `json:"score"`
+
+	// Grace is set when the node had fewer than MinSamples samples and got
+	// the neutral score regardless of its other inputs.
+	Grace bool `json:"grace_period"`
+
+	// Latency is the statistic selected by LatencySignal; LatencyScore is
+	// its 0-1 sub-score and LatencyContribution that times LatencyWeight.
+	Latency             time.Duration `json:"latency_ns"`
+	LatencyScore        float64       `json:"latency_score"`
+	LatencyContribution float64       `json:"latency_contribution"`
+
+	// ThroughputScore is the 0-1 throughput sub-score (0.5 with no
+	// transfers) and ThroughputContribution that times ThroughputWeight.
+	ThroughputScore        float64 `json:"throughput_score"`
+	ThroughputContribution float64 `json:"throughput_contribution"`
+
+	// GeoMatchLevel is how many leading geo levels the node shares with
+	// the preferred geo (see GeoMatch).
+	GeoMatchLevel   int     `json:"geo_match_level"`
+	GeoBoostApplied bool    `json:"geo_boost_applied"`
+	GeoBoost        float64 `json:"geo_boost"`
+
+	// ErrorPenalty is the multiplier applied for the recent error rate, 1
+	// when none was applied.
+	ErrorPenalty float64 `json:"error_penalty"`
+
+	// ProofPenalty is the multiplier applied for a non-closed circuit, 1
+	// when none was applied.
+	ProofPenalty float64 `json:"proof_penalty"`
+
+	Components []ScoreComponent `json:"components"`
+	Inputs     NodeView         `json:"inputs"`
+	Config     ExplainConfig    `json:"config"`
+}
+
+// ExplainConfig is the subset of Config that went into a score.
+type ExplainConfig struct {
+	Scorer           string          `json:"scorer"`
+	LatencyWeight    float64         `json:"latency_weight"`
+	GeoBoost         float64         `json:"geo_boost"`
+	GeoTierBoosts    []float64       `json:"geo_tier_boosts,omitempty"`
+	GeoDistances     GeoMatrix       `json:"geo_distances,omitempty"`
+	GeoDistanceCap   time.Duration   `json:"geo_distance_cap_ns"`
+	MinSamples       int             `json:"min_samples"`
+	LatencySignal    LatencySignal   `json:"latency_signal"`
+	LatencyMaxAge    time.Duration   `json:"latency_max_age_ns"`
+	EWMAHalfLife     time.Duration   `json:"ewma_half_life_ns"`
+	QuantileBackend  QuantileBackend `json:"quantile_backend"`
+	ThroughputWeight float64         `json:"throughput_weight"`
+	TargetThroughput float64         `json:"target_throughput_bps"`
+	ErrorRateWeight  float64         `json:"error_rate_weight"`
+	ProofGraceMisses int             `json:"proof_grace_misses"`
+}
+
+// Explain scores nodeID like Score and returns the full breakdown. Unknown
+// nodes come back with Known false and a zero score.
+func (e *Engine) Explain(nodeID, preferredGeo string) Explanation {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	scorer := e.scorer()
+	x := Explanation{
+		NodeID:       nodeID,
+		ErrorPenalty: 1,
+		ProofPenalty: 1,
+		Config: ExplainConfig{
+			Scorer:           fmt.Sprintf("%T", scorer),
+			LatencyWeight:    e.config.LatencyWeight,
+			GeoBoost:         e.config.GeoBoost,
+			GeoTierBoosts:    e.config.GeoTierBoosts,
+			GeoDistances:     e.config.GeoDistances,
+			GeoDistanceCap:   e.config.GeoDistanceCap,
+			MinSamples:       e.config.MinSamples,
+			LatencySignal:    e.config.LatencySignal,
+			LatencyMaxAge:    e.config.LatencyMaxAge,
+			EWMAHalfLife:     e.config.EWMAHalfLife,
+			QuantileBackend:  e.config.QuantileBackend,
+			ThroughputWeight: e.config.ThroughputWeight,
+			TargetThroughput: e.config.TargetThroughput,
+			ErrorRateWeight:  e.config.ErrorRateWeight,
+			ProofGraceMisses: e.config.ProofGraceMisses,
+		},
+	}
+	ns, ok := e.nodes[nodeID]
+	if !ok {
+		x.Inputs = NodeView{NodeID: nodeID, PreferredGeo: preferredGeo}
+		return x
+	}
+
+	x.Known = true
+	x.Inputs = e.viewLocked(nodeID, ns, preferredGeo, e.now())
+	x.Score, x.Components = scorer.Score(x.Inputs, e.config)
+
+	x.GeoMatchLevel = GeoMatch(x.Inputs.GeoLabel, preferredGeo)
+	x.Latency = x.Inputs.P95Latency
+	if e.config.LatencySignal == SignalEWMA {
+		x.Latency = x.Inputs.EWMALatency
+	}
+	for _, c := range x.Components {
+		switch c.Name {
+		case "grace":
+			x.Grace = true
+		case "latency":
+			x.LatencyScore = c.Value
+		case "latency_weighted":
+			x.LatencyContribution = c.Value
+		case "throughput":
+			x.ThroughputScore = c.Value
+		case "throughput_weighted":
+			x.ThroughputContribution = c.Value
+		case "error_penalty":
+			x.ErrorPenalty = c.Value
+		case "geo_boost":
+			x.GeoBoostApplied = true
+			x.GeoBoost = c.Value
+		case "proof_penalty":
+			x.ProofPenalty = c.Value
+		}
+	}
+	return x
+}
@@ -0,0 +1,87 @@
+package policy
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestExplain_Components(t *testing.T) {
+	e, _ := newTestEngine()
+	seedNode(e, "node-1", 2*time.Second)
+	e.SetGeoLabel("node-1", "eu-west")
+	trip(e, "node-1")
+
+	x := e.Explain("node-1", "eu-west")
+	if !x.Known || x.Grace {
+		t.Fatalf("expected known node out of grace, got %+v", x)
+	}
+	if x.Score != e.Score("node-1", "eu-west").Score {
+		t.Fatalf("Explain score %v differs from Score", x.Score)
+	}
+	if x.Latency != 2*time.Second || math.Abs(x.LatencyScore-0.8) > 1e-9 {
+		t.Fatalf("unexpected latency terms: %v %v", x.Latency, x.LatencyScore)
+	}
+	if math.Abs(x.LatencyContribution-0.56) > 1e-9 {
+		t.Fatalf("expected contribution 0.56, got %v", x.LatencyContribution)
+	}
+	if !x.GeoBoostApplied || x.GeoBoost != 0.1 || x.ProofPenalty != 0.5 {
+		t.Fatalf("expected geo boost and proof penalty, got %+v", x)
+	}
+	if x.Config.LatencyWeight != 0.7 || x.Config.Scorer != "policy.DefaultScorer" || x.Config.ProofGraceMisses != 2 {
+		t.Fatalf("unexpected config echo %+v", x.Config)
+	}
+}
+
+func TestExplain_GeoDistanceConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GeoDistances = GeoMatrix{"us-east": {"eu-west": 80 * time.Millisecond}}
+	e := NewEngine(cfg)
+	seedNode(e, "node-1", time.Second)
+	e.SetGeoLabel("node-1", "eu-west")
+
+	x := e.Explain("node-1", "us-east")
+	if math.Abs(x.GeoBoost-0.06) > 1e-9 {
+		t.Fatalf("expected distance-scaled boost, got %v", x.GeoBoost)
+	}
+	if d, _ := x.Config.GeoDistances.Distance("us-east", "eu-west"); d != 80*time.Millisecond || x.Config.GeoDistanceCap != 200*time.Millisecond {
+		t.Fatalf("expected geo distances in config echo, got %+v", x.Config)
+	}
+}
+
+func TestExplain_GraceAndUnknown(t *testing.T) {
+	e, _ := newTestEngine()
+	e.RecordLatency("node-1", time.Second)
+
+	x := e.Explain("node-1", "")
+	if !x.Grace || x.Score != 0.5 || x.ProofPenalty != 1 || x.GeoBoostApplied {
+		t.Fatalf("expected grace override, got %+v", x)
+	}
+
+	if x := e.Explain("node-missing", ""); x.Known || x.Score != 0 {
+		t.Fatalf("expected unknown node, got %+v", x)
+	}
+}
+
+func TestExplain_JSON(t *testing.T) {
+	e, _ := newTestEngine()
+	seedNode(e, "node-1", time.Second)
+
+	b, err := json.Marshal(e.Explain("node-1", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out map[string]any
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []string{"score", "grace_period", "latency_score", "proof_penalty", "components", "inputs", "config"} {
+		if _, ok := out[k]; !ok {
+			t.Errorf("missing %q in %s", k, b)
+		}
+	}
+	if in := out["inputs"].(map[string]any); in["circuit"] != "closed" {
+		t.Errorf("expected circuit by name, got %v", in["circuit"])
+	}
+}
@@ -0,0 +1,143 @@
+package policy
+
+import (
+	"strings"
+	"time"
+)
+
+// GeoPath is a geo label split into levels, broadest first, e.g.
+// ["eu", "de", "fra1"].
+type GeoPath []string
+
+// regionContinent maps the continent-level region prefixes cloud providers
+// use to a continent code.
+var regionContinent = map[string]string{
+	"af": "af", "ap": "as", "as": "as", "eu": "eu", "me": "as", "na": "na", "oc": "oc", "sa": "sa",
+}
+
+// countryContinent maps ISO 3166 country codes to a continent code, so "de"
+// and "eu-west" share the "eu" level.
+var countryContinent = map[string]string{
+	"us": "na", "ca": "na", "mx": "na",
+	"br": "sa", "ar": "sa", "cl": "sa", "co": "sa",
+	"gb": "eu", "uk": "eu", "ie": "eu", "fr": "eu", "de": "eu", "nl": "eu", "be": "eu", "es": "eu",
+	"pt": "eu", "it": "eu", "ch": "eu", "at": "eu", "se": "eu", "no": "eu", "fi": "eu", "dk": "eu", "pl": "eu",
+	"jp": "as", "kr": "as", "cn": "as", "hk": "as", "tw": "as", "sg": "as", "in": "as", "id": "as", "ae": "as", "il": "as",
+	"au": "oc", "nz": "oc",
+	"za": "af", "ng": "af", "ke": "af", "eg": "af",
+}
+
+// ParseGeo splits a geo label into levels. Labels may be explicit paths
+// ("eu/de/fra1") or dash-separated region names ("us-east-1"). A leading
+// country code gets its continent prepended and a region prefix is mapped
+// to its continent, so "us-east" parses as ["na", "us", "east"], "de" as
+// ["eu", "de"] and "ap-south" as ["as", "south"]. Matching is case
+// insensitive. An empty label parses to nil.
+func ParseGeo(label string) GeoPath {
+	label = strings.ToLower(strings.TrimSpace(label))
+	if label == "" {
+		return nil
+	}
+	if strings.Contains(label, "/") {
+		return GeoPath(strings.Split(label, "/"))
+	}
+	parts := strings.Split(label, "-")
+	if cont, ok := regionContinent[parts[0]]; ok {
+		parts[0] = cont
+	} else if cont, ok := countryContinent[parts[0]]; ok {
+		parts = append([]string{cont}, parts...)
+	}
+	return GeoPath(parts)
+}
+
+// GeoMatch returns how many leading levels two labels share: 0 for no
+// match, len(path) for identical labels.
+func GeoMatch(a, b string) int {
+	pa, pb := ParseGeo(a), ParseGeo(b)
+	n := 0
+	for n < len(pa) && n < len(pb) && pa[n] == pb[n] {
+		n++
+	}
+	return n
+}
+
+// GeoMatrix holds measured latency between regions, keyed by geo label.
+// Lookups are symmetric and fall back to broader levels, so an entry for
+// "na/us" covers "na/us/east". Keys may be written in any form ParseGeo
+// accepts ("us-east" or "na/us/east"); the engine and LoadConfig
+// normalize them.
+type GeoMatrix map[string]map[string]time.Duration
+
+// geoKey is the normalized matrix key for a label: its ParseGeo levels
+// joined with "/".
+func geoKey(label string) string {
+	return strings.Join(ParseGeo(label), "/")
+}
+
+// normalized returns a copy of m with every key in geoKey form.
+func (m GeoMatrix) normalized() GeoMatrix {
+	if m == nil {
+		return nil
+	}
+	out := make(GeoMatrix, len(m))
+	for a, row := range m {
+		ka := geoKey(a)
+		if out[ka] == nil {
+			out[ka] = make(map[string]time.Duration, len(row))
+		}
+		for b, d := range row {
+			out[ka][geoKey(b)] = d
+		}
+	}
+	return out
+}
+
+// Distance returns the latency between two labels, if known.
+func (m GeoMatrix) Distance(a, b string) (time.Duration, bool) {
+	pa, pb := ParseGeo(a), ParseGeo(b)
+	for i := len(pa); i > 0; i-- {
+		ka := strings.Join(pa[:i], "/")
+		for j := len(pb); j > 0; j-- {
+			kb := strings.Join(pb[:j], "/")
+			if d, ok := m[ka][kb]; ok {
+				return d, true
+			}
+			if d, ok := m[kb][ka]; ok {
+				return d, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// geoBoost returns the boost for a node in nodeGeo serving a viewer in
+// preferredGeo. A label that is identical to, or a prefix of, the other
+// always gets GeoBoost, so "us-east-1" counts as inside "us-east".
+// Otherwise a GeoDistances entry scales GeoBoost linearly down to zero at
+// GeoDistanceCap, and failing that GeoTierBoosts applies by the number of
+// shared levels, capped at the last tier.
+func (cfg Config) geoBoost(nodeGeo, preferredGeo string) float64 {
+	if nodeGeo == "" || preferredGeo == "" {
+		return 0
+	}
+	level := GeoMatch(nodeGeo, preferredGeo)
+	if level > 0 && (level == len(ParseGeo(nodeGeo)) || level == len(ParseGeo(preferredGeo))) {
+		return cfg.GeoBoost
+	}
+	if cfg.GeoDistances != nil && cfg.GeoDistanceCap > 0 {
+		if d, ok := cfg.GeoDistances.Distance(nodeGeo, preferredGeo); ok {
+			f := 1 - float64(d)/float64(cfg.GeoDistanceCap)
+			if f < 0 {
+				f = 0
+			}
+			return cfg.GeoBoost * f
+		}
+	}
+	if level > len(cfg.GeoTierBoosts) {
+		level = len(cfg.GeoTierBoosts)
+	}
+	if level > 0 {
+		return cfg.GeoTierBoosts[level-1]
+	}
+	return 0
+}
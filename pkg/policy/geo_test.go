@@ -0,0 +1,127 @@
+package policy
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGeo_Parse(t *testing.T) {
+	cases := map[string]GeoPath{
+		"":           nil,
+		"us-east":    {"na", "us", "east"},
+		"US-East-1":  {"na", "us", "east", "1"},
+		"de":         {"eu", "de"},
+		"eu-west":    {"eu", "west"},
+		"ap-south":   {"as", "south"},
+		"eu/de/fra1": {"eu", "de", "fra1"},
+		"moon-base":  {"moon", "base"},
+	}
+	for in, want := range cases {
+		if got := ParseGeo(in); !reflect.DeepEqual(got, want) {
+			t.Errorf("ParseGeo(%q) = %v, want %v", in, got, want)
+		}
+	}
+
+	if got := GeoMatch("us-east", "us-west"); got != 2 {
+		t.Errorf("us-east/us-west: expected 2 shared levels, got %d", got)
+	}
+	if got := GeoMatch("de", "eu-west"); got != 1 {
+		t.Errorf("de/eu-west: expected 1 shared level, got %d", got)
+	}
+	if got := GeoMatch("us-east", "ap-south"); got != 0 {
+		t.Errorf("us-east/ap-south: expected no match, got %d", got)
+	}
+}
+
+func TestGeo_TieredBoost(t *testing.T) {
+	e, _ := newTestEngine()
+	for id, geo := range map[string]string{"east": "us-east", "west": "us-west", "ca": "ca", "ap": "ap-south"} {
+		seedNode(e, id, 100*time.Millisecond)
+		e.SetGeoLabel(id, geo)
+	}
+
+	boost := func(id string) float64 { return e.Explain(id, "us-east").GeoBoost }
+	if b := boost("east"); b != 0.1 {
+		t.Fatalf("exact match: expected 0.1, got %v", b)
+	}
+	if b := boost("west"); b != 0.06 {
+		t.Fatalf("same country: expected 0.06, got %v", b)
+	}
+	if b := boost("ca"); b != 0.03 {
+		t.Fatalf("same continent: expected 0.03, got %v", b)
+	}
+	if x := e.Explain("ap", "us-east"); x.GeoBoostApplied || x.GeoMatchLevel != 0 {
+		t.Fatalf("expected no boost across continents, got %+v", x)
+	}
+
+	got := ids(e.Select(SelectRequest{PreferredGeo: "us-east"}))
+	if !reflect.DeepEqual(got, []string{"east", "west", "ca", "ap"}) {
+		t.Fatalf("expected geo-tiered order, got %v", got)
+	}
+}
+
+func TestGeo_DeeperLabelGetsFullBoost(t *testing.T) {
+	e, _ := newTestEngine()
+	for id, geo := range map[string]string{"east-1": "us-east-1", "west-2": "us-west-2", "fra1": "eu/de/fra1", "ber1": "eu/de/ber/1"} {
+		seedNode(e, id, 100*time.Millisecond)
+		e.SetGeoLabel(id, geo)
+	}
+
+	if b := e.Explain("east-1", "us-east").GeoBoost; b != 0.1 {
+		t.Fatalf("node one level below the viewer: expected 0.1, got %v", b)
+	}
+	if b := e.Explain("west-2", "us-east").GeoBoost; b != 0.06 {
+		t.Fatalf("same country: expected 0.06, got %v", b)
+	}
+	if b := e.Explain("east-1", "us").GeoBoost; b != 0.1 {
+		t.Fatalf("viewer label a prefix of the node's: expected 0.1, got %v", b)
+	}
+	// Three shared levels with only two tiers uses the last tier.
+	if b := e.Explain("ber1", "eu/de/ber/2").GeoBoost; b != 0.06 {
+		t.Fatalf("expected match past the last tier capped at 0.06, got %v", b)
+	}
+	if b := e.Explain("fra1", "eu/de/ber").GeoBoost; b != 0.06 {
+		t.Fatalf("same country: expected 0.06, got %v", b)
+	}
+}
+
+func TestGeo_DistanceMatrix(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GeoDistances = GeoMatrix{
+		"na/us/east": {"eu/west": 80 * time.Millisecond},
+		"na/us":      {"as": 300 * time.Millisecond},
+	}
+	cfg.GeoBoost = 0.1
+
+	if b := cfg.geoBoost("eu-west", "us-east"); math.Abs(b-0.06) > 1e-9 {
+		t.Fatalf("expected boost scaled by 80/200ms, got %v", b)
+	}
+	// Falls back to the broader "na/us" entry, beyond the cap.
+	if b := cfg.geoBoost("ap-south", "us-west"); b != 0 {
+		t.Fatalf("expected no boost past the cap, got %v", b)
+	}
+	// No entry: tier boosts still apply.
+	if b := cfg.geoBoost("us-west", "us-east"); b != 0.06 {
+		t.Fatalf("expected tier boost without a matrix entry, got %v", b)
+	}
+}
+
+func TestGeo_DistanceMatrixDashKeys(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GeoDistances = GeoMatrix{"us-east": {"eu-west": 80 * time.Millisecond}}
+	cfg.GeoBoost = 0.1
+	e := NewEngine(cfg)
+	if b := e.Config().geoBoost("eu-west", "us-east"); math.Abs(b-0.06) > 1e-9 {
+		t.Fatalf("expected dash-form keys to scale the boost, got %v", b)
+	}
+
+	cfg.GeoDistances = GeoMatrix{"US-East": {"eu-west": 200 * time.Millisecond}}
+	if err := e.UpdateConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if b := e.Config().geoBoost("eu-west", "us-east"); b != 0 {
+		t.Fatalf("expected reloaded matrix at the cap, got %v", b)
+	}
+}
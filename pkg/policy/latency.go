@@ -0,0 +1,43 @@
+package policy
+
+import (
+	"math"
+	"time"
+)
+
+// LatencySignal selects which latency statistic drives the score.
+type LatencySignal string
+
+const (
+	// SignalP95 scores on the P95 of the sample window.
+	SignalP95 LatencySignal = "p95"
+
+	// SignalEWMA scores on a time-decayed exponentially weighted moving
+	// average, which reacts faster to a node recovering or degrading.
+	SignalEWMA LatencySignal = "ewma"
+)
+
+// latencySample is one observation with the time it was recorded.
+type latencySample struct {
+	d  time.Duration
+	at time.Time
+}
+
+// updateEWMA folds a new sample into the time-decayed average. Every sample
+// carries weight 1 and the accumulated weight of older samples halves every
+// halfLife, so a burst of samples averages evenly while a sample after a
+// long quiet gap all but replaces the stale history.
+func (ns *nodeState) updateEWMA(d time.Duration, now time.Time, halfLife time.Duration) {
+	if ns.ewmaAt.IsZero() || halfLife <= 0 {
+		ns.ewma, ns.ewmaWeight, ns.ewmaAt = d, 1, now
+		return
+	}
+	elapsed := now.Sub(ns.ewmaAt)
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	old := ns.ewmaWeight * math.Exp2(-float64(elapsed)/float64(halfLife))
+	ns.ewmaWeight = old + 1
+	ns.ewma = time.Duration((old*float64(ns.ewma) + float64(d)) / ns.ewmaWeight)
+	ns.ewmaAt = now
+}
@@ -0,0 +1,94 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLatency_MaxAgeEviction(t *testing.T) {
+	e, clock := newTestEngine()
+	seedNode(e, "node-1", 8*time.Second)
+	if s := e.Score("node-1", ""); s.P95Latency != 8*time.Second {
+		t.Fatalf("expected stale P95 of 8s, got %v", s.P95Latency)
+	}
+
+	// Gone quiet for longer than LatencyMaxAge: old samples no longer count.
+	clock.Advance(31 * time.Minute)
+	s := e.Score("node-1", "")
+	if s.SampleCount != 0 || s.Score != 0.5 {
+		t.Fatalf("expected node back in grace period, got %+v", s)
+	}
+
+	// Fresh samples alone determine the new P95.
+	seedNode(e, "node-1", 20*time.Millisecond)
+	if s := e.Score("node-1", ""); s.P95Latency != 20*time.Millisecond || s.SampleCount != 10 {
+		t.Fatalf("expected fresh P95 of 20ms over 10 samples, got %+v", s)
+	}
+}
+
+func TestLatency_NoMaxAgeKeepsSamples(t *testing.T) {
+	e, clock := newTestEngine()
+	e.config.LatencyMaxAge = 0
+	seedNode(e, "node-1", time.Second)
+	clock.Advance(24 * time.Hour)
+	if got := e.Score("node-1", "").SampleCount; got != 10 {
+		t.Fatalf("expected samples kept without max age, got %d", got)
+	}
+}
+
+func TestLatency_EWMASignal(t *testing.T) {
+	e, clock := newTestEngine()
+	e.config.LatencySignal = SignalEWMA
+
+	// Slow history, then the node recovers after a quiet period.
+	seedNode(e, "node-1", 5*time.Second)
+	clock.Advance(10 * time.Minute)
+	for i := 0; i < 5; i++ {
+		e.RecordLatency("node-1", 50*time.Millisecond)
+		clock.Advance(time.Second)
+	}
+
+	s := e.Score("node-1", "")
+	if s.EWMALatency > 100*time.Millisecond {
+		t.Fatalf("expected EWMA to track recovery, got %v", s.EWMALatency)
+	}
+	if s.P95Latency != 5*time.Second {
+		t.Fatalf("expected P95 still dominated by slow window, got %v", s.P95Latency)
+	}
+
+	p95Engine, _ := newTestEngine()
+	p95Engine.SetClock(clock.Now)
+	seedNode(p95Engine, "node-1", 5*time.Second)
+	for i := 0; i < 5; i++ {
+		p95Engine.RecordLatency("node-1", 50*time.Millisecond)
+	}
+	if s.Score <= p95Engine.Score("node-1", "").Score {
+		t.Fatal("expected EWMA-scored node to rank above the same history scored by P95")
+	}
+}
+
+func TestLatency_EWMABurstAveragesEvenly(t *testing.T) {
+	e, _ := newTestEngine()
+	e.RecordLatency("node-1", 100*time.Millisecond)
+	e.RecordLatency("node-1", 300*time.Millisecond)
+	if got := e.Score("node-1", "").EWMALatency; got != 0 {
+		t.Fatalf("EWMA is only reported once past the grace period, got %v", got)
+	}
+	if got := e.nodes["node-1"].ewma; got != 200*time.Millisecond {
+		t.Fatalf("expected simultaneous samples to average to 200ms, got %v", got)
+	}
+}
+
+func TestSnapshot_RestoresVersion1(t *testing.T) {
+	e, _ := newTestEngine()
+	v1 := `{"version": 1, "taken_at": "2026-01-01T00:00:00Z",
+		"nodes": {"node-1": {"latencies_ns": [1000000, 2000000, 3000000, 4000000, 5000000,
+		6000000, 7000000, 8000000, 9000000, 10000000], "circuit": "closed"}}}`
+	if err := e.Restore(strings.NewReader(v1)); err != nil {
+		t.Fatal(err)
+	}
+	if got := e.Score("node-1", "").SampleCount; got != 10 {
+		t.Fatalf("expected 10 samples from v1 snapshot, got %d", got)
+	}
+}
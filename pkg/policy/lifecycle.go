@@ -0,0 +1,142 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+)
+
+// ErrEmptyNodeID is returned when registering a node with an empty ID.
+var ErrEmptyNodeID = errors.New("policy: empty node ID")
+
+// NodeInfo describes a node the engine is tracking.
+type NodeInfo struct {
+	NodeID       string       `json:"node_id"`
+	GeoLabel     string       `json:"geo_label,omitempty"`
+	Registered   bool         `json:"registered"`
+	RegisteredAt time.Time    `json:"registered_at,omitempty"`
+	LastSeen     time.Time    `json:"last_seen"`
+	SampleCount  int          `json:"sample_count"`
+	Circuit      CircuitState `json:"circuit"`
+}
+
+// RegisterNode adds nodeID to the engine (or marks an implicitly created
+// node as registered) and clears any tombstone left by DeregisterNode.
+// Registering an already registered node only refreshes its activity time.
+func (e *Engine) RegisterNode(nodeID string) error {
+	if nodeID == "" {
+		return ErrEmptyNodeID
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := e.now()
+	delete(e.tombstones, nodeID)
+	ns, ok := e.nodes[nodeID]
+	if !ok {
+		ns = &nodeState{latency: e.newEstimator()}
+		e.nodes[nodeID] = ns
+	}
+	if !ns.registered {
+		ns.registered = true
+		ns.registeredAt = now
+	}
+	ns.lastSeen = now
+	return nil
+}
+
+// DeregisterNode drops all state for nodeID and leaves a tombstone, so
+// samples arriving within TombstoneTTL are dropped instead of recreating
+// the node. It reports whether the node was known.
+func (e *Engine) DeregisterNode(nodeID string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	_, ok := e.nodes[nodeID]
+	delete(e.nodes, nodeID)
+	if e.config.TombstoneTTL > 0 {
+		e.tombstones[nodeID] = e.now()
+	}
+	return ok
+}
+
+// Nodes lists every tracked node, sorted by ID.
+func (e *Engine) Nodes() []NodeInfo {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	now := e.now()
+	out := make([]NodeInfo, 0, len(e.nodes))
+	for id, ns := range e.nodes {
+		out = append(out, NodeInfo{
+			NodeID:       id,
+			GeoLabel:     ns.geoLabel,
+			Registered:   ns.registered,
+			RegisteredAt: ns.registeredAt,
+			LastSeen:     ns.lastSeen,
+			SampleCount:  ns.latency.Count(now),
+			Circuit:      ns.circuit,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].NodeID < out[j].NodeID })
+	return out
+}
+
+// RejectedSamples returns how many samples were dropped because their node
+// was tombstoned or, with RequireRegistration, not registered.
+func (e *Engine) RejectedSamples() uint64 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.rejected
+}
+
+// EvictIdle removes unregistered nodes with no activity for NodeIdleTTL,
+// and expired tombstones. Registered nodes are only removed by
+// DeregisterNode. It returns the evicted node IDs, sorted.
+func (e *Engine) EvictIdle() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := e.now()
+	var evicted []string
+	if ttl := e.config.NodeIdleTTL; ttl > 0 {
+		for id, ns := range e.nodes {
+			if !ns.registered && now.Sub(ns.lastSeen) > ttl {
+				delete(e.nodes, id)
+				evicted = append(evicted, id)
+			}
+		}
+	}
+	for id := range e.tombstones {
+		if !e.tombstoned(id, now) {
+			delete(e.tombstones, id)
+		}
+	}
+	sort.Strings(evicted)
+	return evicted
+}
+
+// RunEvictor calls EvictIdle every interval until ctx is cancelled.
+// Evicted IDs are passed to onEvict (if not nil).
+func (e *Engine) RunEvictor(ctx context.Context, interval time.Duration, onEvict func([]string)) error {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			if evicted := e.EvictIdle(); len(evicted) > 0 && onEvict != nil {
+				onEvict(evicted)
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// tombstoned reports whether nodeID was deregistered within TombstoneTTL.
+// Caller must hold e.mu.
+func (e *Engine) tombstoned(nodeID string, now time.Time) bool {
+	at, ok := e.tombstones[nodeID]
+	return ok && now.Sub(at) < e.config.TombstoneTTL
+}
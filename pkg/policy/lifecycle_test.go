@@ -0,0 +1,124 @@
+package policy
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLifecycle_RegisterAndList(t *testing.T) {
+	e, clock := newTestEngine()
+	if err := e.RegisterNode(""); err != ErrEmptyNodeID {
+		t.Fatalf("expected ErrEmptyNodeID, got %v", err)
+	}
+	e.RegisterNode("b")
+	e.SetGeoLabel("b", "us-east")
+	clock.Advance(time.Minute)
+	e.RecordLatency("a", time.Millisecond) // implicit
+
+	nodes := e.Nodes()
+	if len(nodes) != 2 || nodes[0].NodeID != "a" || nodes[1].NodeID != "b" {
+		t.Fatalf("expected a, b sorted, got %+v", nodes)
+	}
+	if nodes[0].Registered || !nodes[1].Registered || nodes[1].GeoLabel != "us-east" {
+		t.Fatalf("unexpected metadata %+v", nodes)
+	}
+	if !nodes[0].LastSeen.Equal(clock.Now()) || !nodes[1].RegisteredAt.Equal(clock.Now().Add(-time.Minute)) {
+		t.Fatalf("unexpected times %+v", nodes)
+	}
+}
+
+func TestLifecycle_DeregisterTombstone(t *testing.T) {
+	e, clock := newTestEngine()
+	seedNode(e, "node-1", time.Millisecond)
+
+	if !e.DeregisterNode("node-1") {
+		t.Fatal("expected known node")
+	}
+	e.RecordLatency("node-1", time.Millisecond)
+	e.RecordProofResult("node-1", true)
+	if len(e.Nodes()) != 0 || e.RejectedSamples() != 2 {
+		t.Fatalf("expected late samples dropped, got %+v (%d rejected)", e.Nodes(), e.RejectedSamples())
+	}
+
+	clock.Advance(time.Hour)
+	e.RecordLatency("node-1", time.Millisecond)
+	if len(e.Nodes()) != 1 {
+		t.Fatal("expected node recreated after tombstone expired")
+	}
+
+	e.DeregisterNode("node-1")
+	e.RegisterNode("node-1")
+	e.RecordLatency("node-1", time.Millisecond)
+	if n := e.Nodes(); len(n) != 1 || n[0].SampleCount != 1 {
+		t.Fatalf("expected re-registration to clear tombstone, got %+v", n)
+	}
+}
+
+func TestLifecycle_RequireRegistration(t *testing.T) {
+	e, _ := newTestEngine()
+	e.config.RequireRegistration = true
+
+	e.RecordLatency("typo", time.Millisecond)
+	e.SetGeoLabel("typo", "us-east")
+	e.RecordError("typo", ErrorOther)
+	if len(e.Nodes()) != 0 || e.RejectedSamples() != 3 {
+		t.Fatalf("expected unregistered samples rejected, got %+v", e.Nodes())
+	}
+
+	e.RegisterNode("node-1")
+	e.RecordLatency("node-1", time.Millisecond)
+	if n := e.Nodes(); len(n) != 1 || n[0].SampleCount != 1 {
+		t.Fatalf("expected registered node accepted, got %+v", n)
+	}
+}
+
+func TestLifecycle_EvictIdle(t *testing.T) {
+	e, clock := newTestEngine()
+	e.RegisterNode("registered")
+	e.RecordLatency("quiet", time.Millisecond)
+	e.RecordLatency("busy", time.Millisecond)
+	e.DeregisterNode("gone")
+
+	clock.Advance(23 * time.Hour)
+	e.RecordLatency("busy", time.Millisecond)
+	clock.Advance(2 * time.Hour)
+
+	if got := e.EvictIdle(); !reflect.DeepEqual(got, []string{"quiet"}) {
+		t.Fatalf("expected quiet evicted, got %v", got)
+	}
+	if len(e.tombstones) != 0 {
+		t.Fatalf("expected expired tombstone purged, got %v", e.tombstones)
+	}
+	var kept []string
+	for _, n := range e.Nodes() {
+		kept = append(kept, n.NodeID)
+	}
+	if !reflect.DeepEqual(kept, []string{"busy", "registered"}) {
+		t.Fatalf("expected busy and idle registered node kept, got %v", kept)
+	}
+}
+
+func TestSnapshot_RegistrationAndTombstones(t *testing.T) {
+	e, clock := newTestEngine()
+	e.RegisterNode("node-1")
+	e.DeregisterNode("node-2")
+
+	var buf bytes.Buffer
+	if err := e.Snapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	restored, _ := newTestEngine()
+	restored.SetClock(clock.Now)
+	if err := restored.Restore(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(restored.Nodes(), e.Nodes()) {
+		t.Fatalf("restored nodes %+v, want %+v", restored.Nodes(), e.Nodes())
+	}
+	restored.RecordLatency("node-2", time.Millisecond)
+	if restored.RejectedSamples() != 1 {
+		t.Fatal("expected tombstone restored")
+	}
+}
@@ -0,0 +1,288 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+// QuantileBackend selects how per-node latency quantiles are estimated.
+type QuantileBackend string
+
+const (
+	// QuantileExact keeps the last 100 timestamped samples and sorts them
+	// when the window changes. Exact, but O(n log n) after each sample.
+	QuantileExact QuantileBackend = "exact"
+
+	// QuantileHistogram keeps fixed log-spaced buckets in time slices
+	// covering LatencyMaxAge. Constant time per Score and constant memory
+	// per node, with at most ~4% relative error.
+	QuantileHistogram QuantileBackend = "histogram"
+)
+
+// QuantileEstimator tracks a node's latency distribution. Implementations
+// need not be safe for concurrent use: the engine serializes Add under its
+// write lock, and Quantile/Count may run concurrently with each other under
+// its read lock, so they must not mutate state without synchronizing.
+type QuantileEstimator interface {
+	// Add records a sample observed at the given time.
+	Add(d time.Duration, at time.Time)
+
+	// Quantile returns the q-quantile (0-1) of samples still in the window
+	// at now, or 0 if there are none.
+	Quantile(q float64, now time.Time) time.Duration
+
+	// Count returns how many samples are still in the window at now.
+	Count(now time.Time) int
+}
+
+// newEstimator builds a latency estimator for a new node from the config.
+func (e *Engine) newEstimator() QuantileEstimator {
+	if e.config.NewEstimator != nil {
+		return e.config.NewEstimator()
+	}
+	if e.config.QuantileBackend == QuantileHistogram {
+		return NewHistogramEstimator(e.config.LatencyMaxAge)
+	}
+	return NewExactEstimator(e.config.LatencyMaxAge)
+}
+
+// --- exact ---
+
+// exactWindowSize is the sliding-window length of ExactEstimator.
+const exactWindowSize = 100
+
+// ExactEstimator keeps the last 100 samples, dropping any older than
+// maxAge, and computes quantiles by sorting. The sorted window is cached
+// until the next Add or until samples age out, so the P50/P95/P99 of one
+// Score share a single sort.
+type ExactEstimator struct {
+	samples []latencySample // oldest first
+	maxAge  time.Duration
+
+	mu         sync.Mutex      // guards the cache; Quantile runs under a read lock
+	sorted     []time.Duration // live sample durations, ascending
+	sortedFrom int             // samples index sorted was built from
+	fresh      bool            // sorted matches samples[sortedFrom:]
+}
+
+// NewExactEstimator creates an exact estimator. A zero maxAge keeps samples
+// until they fall out of the 100-sample window.
+func NewExactEstimator(maxAge time.Duration) *ExactEstimator {
+	return &ExactEstimator{maxAge: maxAge}
+}
+
+func (x *ExactEstimator) Add(d time.Duration, at time.Time) {
+	x.fresh = false
+	x.samples = append(x.samples, latencySample{d: d, at: at})
+	if len(x.samples) > exactWindowSize {
+		x.samples = x.samples[len(x.samples)-exactWindowSize:]
+	}
+	if x.maxAge > 0 {
+		if i := x.live(at); i > 0 {
+			x.samples = append(x.samples[:0:0], x.samples[i:]...)
+		}
+	}
+}
+
+func (x *ExactEstimator) Quantile(q float64, now time.Time) time.Duration {
+	from := x.live(now)
+	if from == len(x.samples) {
+		return 0
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if !x.fresh || x.sortedFrom != from {
+		x.sorted = x.sorted[:0]
+		for _, s := range x.samples[from:] {
+			x.sorted = append(x.sorted, s.d)
+		}
+		slices.Sort(x.sorted)
+		x.sortedFrom, x.fresh = from, true
+	}
+	return x.sorted[quantileIndex(len(x.sorted), q)]
+}
+
+func (x *ExactEstimator) Count(now time.Time) int {
+	return len(x.samples) - x.live(now)
+}
+
+// live returns the index of the oldest sample within maxAge of now.
+func (x *ExactEstimator) live(now time.Time) int {
+	return firstLive(x.samples, func(s latencySample) time.Time { return s.at }, now, x.maxAge)
+}
+
+// latencySamples exposes the raw window for snapshots.
+func (x *ExactEstimator) latencySamples() []latencySample {
+	return x.samples
+}
+
+// --- histogram ---
+
+const (
+	histMin     = 100 * time.Microsecond // lower edge of the first bucket
+	histGrowth  = 1.08                   // each bucket is 8% wider
+	histBuckets = 180                    // 100µs · 1.08^180 ≈ 100s
+	histSlices  = 10                     // time slices covering maxAge
+)
+
+var histLogGrowth = math.Log(histGrowth)
+
+type histSlice struct {
+	Epoch  int64               `json:"epoch"`
+	Total  int                 `json:"total"`
+	Counts [histBuckets]uint32 `json:"counts"`
+}
+
+// HistogramEstimator is a fixed log-bucket histogram split into time slices
+// so samples older than maxAge expire a slice at a time. Memory is constant
+// per node (~7KB) and Quantile/Count cost is independent of sample count.
+type HistogramEstimator struct {
+	sliceWidth time.Duration
+	slices     [histSlices]histSlice
+}
+
+// NewHistogramEstimator creates a histogram estimator. A zero maxAge keeps
+// every sample forever in a single slice.
+func NewHistogramEstimator(maxAge time.Duration) *HistogramEstimator {
+	h := &HistogramEstimator{sliceWidth: maxAge / histSlices}
+	for i := range h.slices {
+		h.slices[i].Epoch = math.MinInt64
+	}
+	return h
+}
+
+func (h *HistogramEstimator) Add(d time.Duration, at time.Time) {
+	epoch := h.epoch(at)
+	s := &h.slices[h.slot(epoch)]
+	if s.Epoch != epoch {
+		*s = histSlice{Epoch: epoch}
+	}
+	s.Counts[histBucket(d)]++
+	s.Total++
+}
+
+func (h *HistogramEstimator) Quantile(q float64, now time.Time) time.Duration {
+	var counts [histBuckets]uint32
+	total := 0
+	cur := h.epoch(now)
+	for i := range h.slices {
+		s := &h.slices[i]
+		if !h.live(s.Epoch, cur) {
+			continue
+		}
+		total += s.Total
+		for b, c := range s.Counts {
+			counts[b] += c
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	rank := uint32(quantileIndex(total, q))
+	var seen uint32
+	for b, c := range counts {
+		seen += c
+		if seen > rank {
+			return histValue(b)
+		}
+	}
+	return histValue(histBuckets - 1)
+}
+
+func (h *HistogramEstimator) Count(now time.Time) int {
+	total := 0
+	cur := h.epoch(now)
+	for i := range h.slices {
+		if h.live(h.slices[i].Epoch, cur) {
+			total += h.slices[i].Total
+		}
+	}
+	return total
+}
+
+// MarshalJSON encodes the histogram for snapshots.
+func (h *HistogramEstimator) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		SliceWidth time.Duration         `json:"slice_width_ns"`
+		Slices     [histSlices]histSlice `json:"slices"`
+	}{h.sliceWidth, h.slices})
+}
+
+// UnmarshalJSON decodes a histogram written by MarshalJSON. The slice
+// width must match the receiver's, otherwise the slices can't be mapped.
+func (h *HistogramEstimator) UnmarshalJSON(b []byte) error {
+	var v struct {
+		SliceWidth time.Duration         `json:"slice_width_ns"`
+		Slices     [histSlices]histSlice `json:"slices"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	if v.SliceWidth != h.sliceWidth {
+		return fmt.Errorf("policy: histogram slice width %v does not match %v", v.SliceWidth, h.sliceWidth)
+	}
+	h.slices = v.Slices
+	return nil
+}
+
+func (h *HistogramEstimator) epoch(t time.Time) int64 {
+	if h.sliceWidth <= 0 {
+		return 0
+	}
+	return t.UnixNano() / int64(h.sliceWidth)
+}
+
+func (h *HistogramEstimator) slot(epoch int64) int {
+	s := int(epoch % histSlices)
+	if s < 0 {
+		s += histSlices
+	}
+	return s
+}
+
+// live reports whether a slice with the given epoch is within maxAge of the
+// current epoch.
+func (h *HistogramEstimator) live(epoch, cur int64) bool {
+	if epoch == math.MinInt64 {
+		return false
+	}
+	return epoch <= cur && epoch > cur-histSlices
+}
+
+// histBucket maps a latency to its bucket index.
+func histBucket(d time.Duration) int {
+	if d <= histMin {
+		return 0
+	}
+	b := int(math.Log(float64(d)/float64(histMin))/histLogGrowth) + 1
+	if b >= histBuckets {
+		b = histBuckets - 1
+	}
+	return b
+}
+
+// histValue returns the representative latency of a bucket: the geometric
+// midpoint of its edges.
+func histValue(b int) time.Duration {
+	if b == 0 {
+		return histMin
+	}
+	lo := float64(histMin) * math.Pow(histGrowth, float64(b-1))
+	return time.Duration(lo * math.Sqrt(histGrowth))
+}
+
+// quantileIndex is the 0-based rank of the q-quantile among n sorted values.
+func quantileIndex(n int, q float64) int {
+	idx := int(float64(n) * q)
+	if idx >= n {
+		idx = n - 1
+	}
+	if idx < 0 {
+		idx = 0
+	}
+	return idx
+}
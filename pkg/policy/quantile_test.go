@@ -0,0 +1,188 @@
+package policy
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+func newHistogramEngine() (*Engine, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	cfg := DefaultConfig()
+	cfg.QuantileBackend = QuantileHistogram
+	e := NewEngine(cfg)
+	e.SetClock(clock.Now)
+	return e, clock
+}
+
+func TestQuantile_HistogramAccuracy(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	h := NewHistogramEstimator(30 * time.Minute)
+	for i := 1; i <= 1000; i++ {
+		h.Add(time.Duration(i)*time.Millisecond, now)
+	}
+	for _, q := range []float64{0.50, 0.95, 0.99} {
+		want := time.Duration(quantileIndex(1000, q)+1) * time.Millisecond
+		got := h.Quantile(q, now)
+		if rel := math.Abs(float64(got-want)) / float64(want); rel > 0.05 {
+			t.Errorf("q=%v: histogram %v, exact %v (%.1f%% off)", q, got, want, rel*100)
+		}
+	}
+	if got := h.Count(now); got != 1000 {
+		t.Fatalf("expected 1000 samples, got %d", got)
+	}
+}
+
+func TestQuantile_ExactCachesSortedWindow(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	x := NewExactEstimator(time.Minute)
+	for i := 100; i >= 1; i-- {
+		x.Add(time.Duration(i)*time.Millisecond, now)
+	}
+	x.Quantile(0.5, now)
+	if n := testing.AllocsPerRun(100, func() {
+		x.Quantile(0.50, now)
+		x.Quantile(0.99, now)
+	}); n != 0 {
+		t.Fatalf("expected cached window to need no allocs, got %v", n)
+	}
+
+	// Add and age-out both invalidate the cache.
+	x.Add(500*time.Millisecond, now.Add(30*time.Second))
+	if got := x.Quantile(0.99, now.Add(30*time.Second)); got != 500*time.Millisecond {
+		t.Fatalf("expected new sample as P99, got %v", got)
+	}
+	if got := x.Quantile(0.50, now.Add(90*time.Second)); got != 500*time.Millisecond {
+		t.Fatalf("expected only the fresh sample after age-out, got %v", got)
+	}
+}
+
+func TestQuantile_HistogramExpiresByAge(t *testing.T) {
+	e, clock := newHistogramEngine()
+	seedNode(e, "node-1", 8*time.Second)
+
+	clock.Advance(31 * time.Minute)
+	if s := e.Score("node-1", ""); s.SampleCount != 0 || s.Score != 0.5 {
+		t.Fatalf("expected stale histogram to return to grace, got %+v", s)
+	}
+
+	seedNode(e, "node-1", 20*time.Millisecond)
+	s := e.Score("node-1", "")
+	if s.SampleCount != 10 || s.P95Latency > 25*time.Millisecond {
+		t.Fatalf("expected only fresh samples, got %+v", s)
+	}
+}
+
+func TestQuantile_PercentilesOnNodeScore(t *testing.T) {
+	for _, backend := range []QuantileBackend{QuantileExact, QuantileHistogram} {
+		t.Run(string(backend), func(t *testing.T) {
+			e, _ := newTestEngine()
+			e.config.QuantileBackend = backend
+			for i := 1; i <= 100; i++ {
+				e.RecordLatency("node-1", time.Duration(i)*10*time.Millisecond)
+			}
+			s := e.Score("node-1", "")
+			if !(s.P50Latency < s.P95Latency && s.P95Latency <= s.P99Latency) {
+				t.Fatalf("expected P50 < P95 <= P99, got %v %v %v", s.P50Latency, s.P95Latency, s.P99Latency)
+			}
+			if s.P50Latency < 480*time.Millisecond || s.P50Latency > 530*time.Millisecond {
+				t.Fatalf("expected P50 near 500ms, got %v", s.P50Latency)
+			}
+		})
+	}
+}
+
+func TestQuantile_CustomEstimator(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MinSamples = 1
+	cfg.NewEstimator = func() QuantileEstimator { return NewExactEstimator(0) }
+	e := NewEngine(cfg)
+	e.RecordLatency("node-1", time.Second)
+	if _, ok := e.nodes["node-1"].latency.(*ExactEstimator); !ok {
+		t.Fatalf("expected custom estimator, got %T", e.nodes["node-1"].latency)
+	}
+}
+
+func TestSnapshot_HistogramRoundTrip(t *testing.T) {
+	e, clock := newHistogramEngine()
+	for i := 1; i <= 50; i++ {
+		e.RecordLatency("node-1", time.Duration(i)*time.Millisecond)
+	}
+	var buf bytes.Buffer
+	if err := e.Snapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	raw := buf.Bytes()
+
+	restored, _ := newHistogramEngine()
+	restored.SetClock(clock.Now)
+	if err := restored.Restore(bytes.NewReader(raw)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := restored.Score("node-1", ""), e.Score("node-1", ""); got != want {
+		t.Fatalf("restored score differs:\n got  %+v\n want %+v", got, want)
+	}
+
+	// Switching backends drops histogram state rather than misreading it.
+	exact, _ := newTestEngine()
+	exact.SetClock(clock.Now)
+	if err := exact.Restore(bytes.NewReader(raw)); err != nil {
+		t.Fatal(err)
+	}
+	if got := exact.Score("node-1", "").SampleCount; got != 0 {
+		t.Fatalf("expected empty window after backend switch, got %d samples", got)
+	}
+}
+
+func TestQuantile_HistogramScoreDoesNotAllocate(t *testing.T) {
+	e, _ := newHistogramEngine()
+	for i := 0; i < 10000; i++ {
+		e.RecordLatency("node-1", time.Duration(i%500)*time.Millisecond)
+	}
+	if n := testing.AllocsPerRun(100, func() { e.Score("node-1", "") }); n != 0 {
+		t.Fatalf("expected 0 allocs per Score, got %v", n)
+	}
+}
+
+// BenchmarkScore shows Score cost per backend as the number of recorded
+// samples grows: the histogram stays flat, the exact backend sorts its
+// window once and reuses it until the next sample.
+func BenchmarkScore(b *testing.B) {
+	for _, backend := range []QuantileBackend{QuantileExact, QuantileHistogram} {
+		for _, n := range []int{100, 10000, 1000000} {
+			b.Run(fmt.Sprintf("%s/samples=%d", backend, n), func(b *testing.B) {
+				cfg := DefaultConfig()
+				cfg.QuantileBackend = backend
+				e := NewEngine(cfg)
+				for i := 0; i < n; i++ {
+					e.RecordLatency("node-1", time.Duration(i%5000)*time.Millisecond)
+				}
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					e.Score("node-1", "")
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkRecordLatency reports the per-node memory of each backend via
+// bytes/op of creating a node and filling it.
+func BenchmarkRecordLatency(b *testing.B) {
+	for _, backend := range []QuantileBackend{QuantileExact, QuantileHistogram} {
+		b.Run(string(backend), func(b *testing.B) {
+			cfg := DefaultConfig()
+			cfg.QuantileBackend = backend
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				e := NewEngine(cfg)
+				for j := 0; j < 1000; j++ {
+					e.RecordLatency("node-1", time.Duration(j)*time.Millisecond)
+				}
+			}
+		})
+	}
+}
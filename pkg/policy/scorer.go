@@ -0,0 +1,233 @@
+package policy
+
+import "time"
+
+// NodeView is a read-only snapshot of one node's state, passed to a Scorer.
+// Latency quantiles are filled in whenever samples exist, even during the
+// grace period; SampleCount tells the scorer how much to trust them.
+type NodeView struct {
+	NodeID            string            `json:"node_id"`
+	PreferredGeo      string            `json:"preferred_geo,omitempty"`
+	SampleCount       int               `json:"sample_count"`
+	P50Latency        time.Duration     `json:"p50_ns"`
+	P95Latency        time.Duration     `json:"p95_ns"`
+	P99Latency        time.Duration     `json:"p99_ns"`
+	EWMALatency       time.Duration     `json:"ewma_ns"`
+	Throughput        float64           `json:"throughput_bps"`
+	TransferCount     int               `json:"transfer_count"`
+	ErrorRate         float64           `json:"error_rate"`
+	ErrorCount        int               `json:"error_count"`
+	ErrorsByKind      map[ErrorKind]int `json:"errors_by_kind,omitempty"`
+	MissedProofs      int               `json:"missed_proofs"`
+	IntegrityFailures int               `json:"integrity_failures"`
+	GeoLabel          string            `json:"geo_label,omitempty"`
+	LastProofCheck    time.Time         `json:"last_proof_check"`
+	Circuit           CircuitState      `json:"circuit"`
+}
+
+// ScoreComponent is one named term of a score breakdown.
+type ScoreComponent struct {
+	Name  string  `json:"name"`
+	Value float64 `json:"value"`
+}
+
+// Scorer turns a node snapshot into a score. Implementations must not
+// retain the view and must be safe for concurrent use; Score is called
+// under the engine's read lock, so it must not call back into the Engine.
+type Scorer interface {
+	Score(n NodeView, cfg Config) (float64, []ScoreComponent)
+}
+
+// ScoreOnlyScorer is implemented by scorers that can compute the score
+// without building the breakdown. Engine.Score and Rank use it; only
+// Explain needs the components.
+type ScoreOnlyScorer interface {
+	ScoreOnly(n NodeView, cfg Config) float64
+}
+
+// scoreOnly returns s's score for n, skipping the breakdown when s
+// implements ScoreOnlyScorer.
+func scoreOnly(s Scorer, n NodeView, cfg Config) float64 {
+	if so, ok := s.(ScoreOnlyScorer); ok {
+		return so.ScoreOnly(n, cfg)
+	}
+	score, _ := s.Score(n, cfg)
+	return score
+}
+
+// ScorerFunc adapts a function to the Scorer interface.
+type ScorerFunc func(n NodeView, cfg Config) (float64, []ScoreComponent)
+
+func (f ScorerFunc) Score(n NodeView, cfg Config) (float64, []ScoreComponent) {
+	return f(n, cfg)
+}
+
+// DefaultScorer is the engine's built-in formula: a neutral 0.5 during the
+// grace period, otherwise latency (P95 or EWMA, see LatencySignal)
+// normalized against a 10s cap times LatencyWeight, plus throughput
+// normalized against TargetThroughput times ThroughputWeight, plus a geo
+// boost (GeoBoost for an exact match, less for a partial or nearby one);
+// then scaled down by the error rate and halved while the
+// circuit is not closed.
+type DefaultScorer struct{}
+
+// latencyCap is the latency at which DefaultScorer's latency term hits 0.
+const latencyCap = 10 * time.Second
+
+func (DefaultScorer) Score(n NodeView, cfg Config) (float64, []ScoreComponent) {
+	var parts []ScoreComponent
+	score := defaultScore(n, cfg, &parts)
+	return score, parts
+}
+
+func (DefaultScorer) ScoreOnly(n NodeView, cfg Config) float64 {
+	return defaultScore(n, cfg, nil)
+}
+
+// defaultScore computes DefaultScorer's formula, appending the breakdown
+// to parts unless it is nil.
+func defaultScore(n NodeView, cfg Config, parts *[]ScoreComponent) float64 {
+	add := func(c ...ScoreComponent) {
+		if parts != nil {
+			*parts = append(*parts, c...)
+		}
+	}
+
+	// Grace period: not enough (fresh) samples yet.
+	if n.SampleCount < cfg.MinSamples {
+		add(ScoreComponent{Name: "grace", Value: 0.5})
+		return 0.5
+	}
+
+	latency := n.P95Latency
+	if cfg.LatencySignal == SignalEWMA {
+		latency = n.EWMALatency
+	}
+
+	// Base latency score: lower is better. Normalize to 0-1.
+	latencyScore := 1.0 - float64(latency)/float64(latencyCap)
+	if latencyScore < 0 {
+		latencyScore = 0
+	}
+	score := latencyScore * cfg.LatencyWeight
+	add(
+		ScoreComponent{Name: "latency", Value: latencyScore},
+		ScoreComponent{Name: "latency_weighted", Value: score},
+	)
+
+	// Throughput: higher is better, neutral until a transfer is recorded.
+	throughputScore := 0.5
+	if n.TransferCount > 0 && cfg.TargetThroughput > 0 {
+		throughputScore = n.Throughput / cfg.TargetThroughput
+		if throughputScore > 1 {
+			throughputScore = 1
+		}
+	}
+	score += throughputScore * cfg.ThroughputWeight
+	add(
+		ScoreComponent{Name: "throughput", Value: throughputScore},
+		ScoreComponent{Name: "throughput_weighted", Value: throughputScore * cfg.ThroughputWeight},
+	)
+
+	if boost := cfg.geoBoost(n.GeoLabel, n.PreferredGeo); boost > 0 {
+		score += boost
+		add(ScoreComponent{Name: "geo_boost", Value: boost})
+	}
+
+	// Error penalty: scale down by the recent failure rate.
+	if n.ErrorRate > 0 && cfg.ErrorRateWeight > 0 {
+		m := 1 - cfg.ErrorRateWeight*n.ErrorRate
+		if m < 0 {
+			m = 0
+		}
+		score *= m
+		add(ScoreComponent{Name: "error_penalty", Value: m})
+	}
+
+	// Proof penalty: degraded until a probe closes the circuit.
+	if n.Circuit != CircuitClosed {
+		score *= 0.5
+		add(ScoreComponent{Name: "proof_penalty", Value: 0.5})
+	}
+	return score
+}
+
+// WeightedScorer is one term of a CompositeScorer.
+type WeightedScorer struct {
+	Name   string
+	Weight float64
+	Scorer Scorer
+}
+
+// CompositeScorer scores a node as the weighted sum of its parts. The
+// breakdown lists each part's weighted contribution under its name,
+// followed by that part's own components prefixed with "name/".
+type CompositeScorer struct {
+	parts []WeightedScorer
+}
+
+// NewCompositeScorer creates a scorer combining parts by weight.
+func NewCompositeScorer(parts ...WeightedScorer) *CompositeScorer {
+	return &CompositeScorer{parts: append([]WeightedScorer(nil), parts...)}
+}
+
+func (c *CompositeScorer) Score(n NodeView, cfg Config) (float64, []ScoreComponent) {
+	var total float64
+	var breakdown []ScoreComponent
+	for _, p := range c.parts {
+		s, parts := p.Scorer.Score(n, cfg)
+		total += p.Weight * s
+		breakdown = append(breakdown, ScoreComponent{Name: p.Name, Value: p.Weight * s})
+		for _, sub := range parts {
+			breakdown = append(breakdown, ScoreComponent{Name: p.Name + "/" + sub.Name, Value: sub.Value})
+		}
+	}
+	return total, breakdown
+}
+
+func (c *CompositeScorer) ScoreOnly(n NodeView, cfg Config) float64 {
+	var total float64
+	for _, p := range c.parts {
+		total += p.Weight * scoreOnly(p.Scorer, n, cfg)
+	}
+	return total
+}
+
+// scorer returns the configured Scorer, or DefaultScorer.
+func (e *Engine) scorer() Scorer {
+	if e.config.Scorer != nil {
+		return e.config.Scorer
+	}
+	return DefaultScorer{}
+}
+
+// viewLocked snapshots a node for a Scorer. Caller must hold e.mu.
+func (e *Engine) viewLocked(nodeID string, ns *nodeState, preferredGeo string, now time.Time) NodeView {
+	v := NodeView{
+		NodeID:            nodeID,
+		PreferredGeo:      preferredGeo,
+		SampleCount:       ns.latency.Count(now),
+		EWMALatency:       ns.ewma,
+		MissedProofs:      ns.missedProofs,
+		IntegrityFailures: ns.integrityFailures,
+		GeoLabel:          ns.geoLabel,
+		LastProofCheck:    ns.lastProof,
+		Circuit:           ns.circuit,
+	}
+	v.Throughput, v.TransferCount = ns.throughput(now, e.config.LatencyMaxAge)
+	v.ErrorRate, v.ErrorCount, v.ErrorsByKind = ns.errorRate(now, e.config.LatencyMaxAge)
+	if v.SampleCount > 0 {
+		v.P50Latency = ns.latency.Quantile(0.50, now)
+		v.P95Latency = ns.latency.Quantile(0.95, now)
+		v.P99Latency = ns.latency.Quantile(0.99, now)
+	}
+	return v
+}
+
+var (
+	_ Scorer          = DefaultScorer{}
+	_ Scorer          = (*CompositeScorer)(nil)
+	_ Scorer          = ScorerFunc(nil)
+	_ ScoreOnlyScorer = DefaultScorer{}
+	_ ScoreOnlyScorer = (*CompositeScorer)(nil)
+)
@@ -0,0 +1,108 @@
+package policy
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestScorer_DefaultMatchesEngine(t *testing.T) {
+	e, _ := newTestEngine()
+	seedNode(e, "node-1", time.Second)
+	e.SetGeoLabel("node-1", "us-east")
+
+	s := e.Score("node-1", "us-east")
+	want := (1-0.1)*0.7 + 0.5*0.2 + 0.1
+	if math.Abs(s.Score-want) > 1e-9 {
+		t.Fatalf("expected %v, got %v", want, s.Score)
+	}
+
+	got, parts := DefaultScorer{}.Score(NodeView{
+		SampleCount:  10,
+		P95Latency:   time.Second,
+		GeoLabel:     "us-east",
+		PreferredGeo: "us-east",
+		Circuit:      CircuitOpen,
+	}, DefaultConfig())
+	if math.Abs(got-want*0.5) > 1e-9 {
+		t.Fatalf("expected halved score %v, got %v", want*0.5, got)
+	}
+	names := make([]string, len(parts))
+	for i, p := range parts {
+		names[i] = p.Name
+	}
+	if len(names) != 6 || names[4] != "geo_boost" || names[5] != "proof_penalty" {
+		t.Fatalf("unexpected breakdown %v", names)
+	}
+}
+
+func TestScorer_GraceBreakdown(t *testing.T) {
+	got, parts := DefaultScorer{}.Score(NodeView{SampleCount: 3}, DefaultConfig())
+	if got != 0.5 || len(parts) != 1 || parts[0].Name != "grace" {
+		t.Fatalf("expected grace override, got %v %v", got, parts)
+	}
+}
+
+func TestScorer_CustomViaConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Scorer = ScorerFunc(func(n NodeView, _ Config) (float64, []ScoreComponent) {
+		return float64(n.IntegrityFailures), nil
+	})
+	e := NewEngine(cfg)
+	e.RecordIntegrityFailure("node-1")
+	e.RecordIntegrityFailure("node-1")
+	if s := e.Score("node-1", ""); s.Score != 2 {
+		t.Fatalf("expected custom scorer result 2, got %v", s.Score)
+	}
+}
+
+func TestScorer_Composite(t *testing.T) {
+	constant := func(v float64) Scorer {
+		return ScorerFunc(func(NodeView, Config) (float64, []ScoreComponent) {
+			return v, []ScoreComponent{{Name: "k", Value: v}}
+		})
+	}
+	c := NewCompositeScorer(
+		WeightedScorer{Name: "a", Weight: 0.25, Scorer: constant(1)},
+		WeightedScorer{Name: "b", Weight: 0.75, Scorer: constant(0.5)},
+	)
+	got, parts := c.Score(NodeView{}, DefaultConfig())
+	if math.Abs(got-0.625) > 1e-9 {
+		t.Fatalf("expected 0.625, got %v", got)
+	}
+	want := []ScoreComponent{{"a", 0.25}, {"a/k", 1}, {"b", 0.375}, {"b/k", 0.5}}
+	if len(parts) != len(want) {
+		t.Fatalf("expected %v, got %v", want, parts)
+	}
+	for i := range want {
+		if parts[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, parts)
+		}
+	}
+}
+
+func TestScorer_ScoreOnlyMatchesScore(t *testing.T) {
+	views := []NodeView{
+		{SampleCount: 3},
+		{SampleCount: 10, P95Latency: time.Second, GeoLabel: "us-east", PreferredGeo: "us-east"},
+		{SampleCount: 10, P95Latency: 2 * time.Second, ErrorRate: 0.5, Circuit: CircuitHalfOpen},
+	}
+	scorers := []Scorer{
+		DefaultScorer{},
+		NewCompositeScorer(
+			WeightedScorer{Name: "default", Weight: 0.5, Scorer: DefaultScorer{}},
+			WeightedScorer{Name: "flat", Weight: 0.5, Scorer: ScorerFunc(func(NodeView, Config) (float64, []ScoreComponent) {
+				return 1, nil
+			})},
+		),
+	}
+	cfg := DefaultConfig()
+	for _, s := range scorers {
+		for _, v := range views {
+			want, _ := s.Score(v, cfg)
+			if got := s.(ScoreOnlyScorer).ScoreOnly(v, cfg); got != want {
+				t.Fatalf("%T %+v: ScoreOnly %v, Score %v", s, v, got, want)
+			}
+		}
+	}
+}
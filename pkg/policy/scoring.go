@@ -8,12 +8,24 @@ import (
 
 // Config holds configurable weights for the scoring engine.
 type Config struct {
-	// LatencyWeight is the weight for P95 latency in the score (0-1).
+	// LatencyWeight is the weight for latency (P95 or EWMA, see
+	// LatencySignal) in the score (0-1).
 	LatencyWeight float64
 
 	// GeoBoost is the additive bonus for nodes matching the preferred geo label.
 	GeoBoost float64
 
+	// GeoTierBoosts gives partial boosts for nodes sharing only some levels
+	// of the preferred geo label (see ParseGeo): GeoTierBoosts[i] applies
+	// when i+1 leading levels match. Should stay below GeoBoost.
+	GeoTierBoosts []float64
+
+	// GeoDistances optionally gives measured latency between regions. When
+	// it has an entry for a pair, the boost is GeoBoost scaled down
+	// linearly to zero at GeoDistanceCap, instead of the tier boost.
+	GeoDistances   GeoMatrix
+	GeoDistanceCap time.Duration
+
 	// MinSamples is the minimum number of latency samples before scoring applies.
 	// Nodes below this threshold get a grace period (neutral score).
 	MinSamples int
@@ -27,6 +39,60 @@ type Config struct {
 
 	// HalfOpenProbeInterval is how often to send a probe to a degraded node.
 	HalfOpenProbeInterval time.Duration
+
+	// LatencyMaxAge evicts latency samples older than this, so a node that
+	// was slow long ago and has since gone quiet returns to the grace
+	// period instead of keeping a stale P95. Zero keeps samples until the
+	// estimator drops them (the exact backend keeps the last 100). The
+	// transfer and error windows use the same age limit.
+	LatencyMaxAge time.Duration
+
+	// LatencySignal selects the latency statistic used for scoring:
+	// SignalP95 (default) or SignalEWMA.
+	LatencySignal LatencySignal
+
+	// EWMAHalfLife is how long it takes an old observation's weight in the
+	// EWMA to halve. Only used with SignalEWMA.
+	EWMAHalfLife time.Duration
+
+	// QuantileBackend selects the per-node latency quantile estimator:
+	// QuantileExact (default) or QuantileHistogram. Ignored when
+	// NewEstimator is set.
+	QuantileBackend QuantileBackend
+
+	// NewEstimator, if set, builds the latency estimator for each new node,
+	// for backends not provided by this package.
+	NewEstimator func() QuantileEstimator `json:"-"`
+
+	// ThroughputWeight is the weight for sustained transfer throughput in
+	// the score (0-1). Nodes with no recorded transfers get a neutral 0.5
+	// for this term.
+	ThroughputWeight float64
+
+	// TargetThroughput is the throughput in bytes/second at which the
+	// throughput term saturates.
+	TargetThroughput float64
+
+	// ErrorRateWeight scales the error-rate penalty: the score is
+	// multiplied by 1 - ErrorRateWeight*errorRate.
+	ErrorRateWeight float64
+
+	// NodeIdleTTL evicts unregistered nodes with no samples or proofs for
+	// this long (see EvictIdle). Zero disables idle eviction.
+	NodeIdleTTL time.Duration
+
+	// TombstoneTTL is how long samples for a deregistered node are dropped,
+	// so in-flight requests finishing after DeregisterNode don't recreate
+	// it. Re-registering clears the tombstone early.
+	TombstoneTTL time.Duration
+
+	// RequireRegistration drops samples for nodes not added with
+	// RegisterNode instead of creating them, so typos and unknown IDs don't
+	// accumulate state. Dropped samples are counted by RejectedSamples.
+	RequireRegistration bool
+
+	// Scorer computes node scores from a NodeView. Nil uses DefaultScorer.
+	Scorer Scorer `json:"-"`
 }
 
 // DefaultConfig returns a Config with sensible defaults.
@@ -34,60 +100,106 @@ func DefaultConfig() Config {
 	return Config{
 		LatencyWeight:         0.7,
 		GeoBoost:              0.1,
+		GeoTierBoosts:         []float64{0.03, 0.06}, // continent, country
+		GeoDistanceCap:        200 * time.Millisecond,
 		MinSamples:            10,
 		ProofGraceMisses:      2,
 		ProofTTL:              24 * time.Hour,
 		HalfOpenProbeInterval: 5 * time.Minute,
+		LatencyMaxAge:         30 * time.Minute,
+		LatencySignal:         SignalP95,
+		EWMAHalfLife:          time.Minute,
+		QuantileBackend:       QuantileExact,
+		ThroughputWeight:      0.2,
+		TargetThroughput:      5 << 20, // 5 MiB/s
+		ErrorRateWeight:       0.5,
+		NodeIdleTTL:           24 * time.Hour,
+		TombstoneTTL:          time.Hour,
 	}
 }
 
 // NodeScore represents the computed score for a storage node.
 type NodeScore struct {
-	NodeID         string
-	Score          float64
-	P95Latency     time.Duration
-	SampleCount    int
-	MissedProofs   int
-	GeoLabel       string
-	HalfOpen       bool
-	LastProofCheck time.Time
+	NodeID            string
+	Score             float64
+	P50Latency        time.Duration
+	P95Latency        time.Duration
+	P99Latency        time.Duration
+	EWMALatency       time.Duration
+	SampleCount       int
+	Throughput        float64 // bytes/second
+	TransferCount     int
+	ErrorRate         float64
+	ErrorCount        int
+	MissedProofs      int
+	IntegrityFailures int
+	GeoLabel          string
+	LastProofCheck    time.Time
+
+	// HalfOpen reports a degraded node: its circuit is open or half-open.
+	// Circuit gives the exact breaker state.
+	HalfOpen bool
+	Circuit  CircuitState
 }
 
 // Engine is the scoring and selection engine.
 type Engine struct {
-	mu     sync.RWMutex
-	config Config
-	nodes  map[string]*nodeState
+	mu         sync.RWMutex
+	config     Config
+	nodes      map[string]*nodeState
+	tombstones map[string]time.Time // deregistered node → when
+	rejected   uint64
+	now        func() time.Time
 }
 
 type nodeState struct {
-	latencies    []time.Duration // sliding window
-	missedProofs int
-	geoLabel     string
-	lastProof    time.Time
-	halfOpen     bool
+	latency           QuantileEstimator
+	transfers         []transferSample // sliding window, oldest first
+	outcomes          []outcomeSample  // sliding window, oldest first
+	ewma              time.Duration
+	ewmaWeight        float64
+	ewmaAt            time.Time
+	missedProofs      int
+	integrityFailures int
+	geoLabel          string
+	lastProof         time.Time
+	circuit           CircuitState
+	lastProbe         time.Time // when the circuit opened or was last probed
+	registered        bool
+	registeredAt      time.Time
+	lastSeen          time.Time // last sample, proof or registration
 }
 
 // NewEngine creates a new scoring engine with the given config.
 func NewEngine(cfg Config) *Engine {
+	cfg.GeoDistances = cfg.GeoDistances.normalized()
 	return &Engine{
-		config: cfg,
-		nodes:  make(map[string]*nodeState),
+		config:     cfg,
+		nodes:      make(map[string]*nodeState),
+		tombstones: make(map[string]time.Time),
+		now:        time.Now,
 	}
 }
 
+// SetClock replaces the engine's time source. Intended for tests.
+func (e *Engine) SetClock(now func() time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.now = now
+}
+
 // RecordLatency adds a latency sample for the given node.
 func (e *Engine) RecordLatency(nodeID string, d time.Duration) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
+	now := e.now()
 	ns := e.getOrCreate(nodeID)
-	ns.latencies = append(ns.latencies, d)
-
-	// Keep sliding window at 100 samples max.
-	if len(ns.latencies) > 100 {
-		ns.latencies = ns.latencies[len(ns.latencies)-100:]
+	if ns == nil {
+		return
 	}
+	ns.latency.Add(d, now)
+	ns.updateEWMA(d, now, e.config.EWMAHalfLife)
 }
 
 // RecordProofResult records a proof verification result for the given node.
@@ -96,23 +208,47 @@ func (e *Engine) RecordProofResult(nodeID string, passed bool) {
 	defer e.mu.Unlock()
 
 	ns := e.getOrCreate(nodeID)
-	ns.lastProof = time.Now()
+	if ns == nil {
+		return
+	}
+	ns.lastProof = e.now()
 	if passed {
+		// A passing proof clears missed proofs, not integrity failures:
+		// a node that keeps serving corrupt data stays tripped.
 		ns.missedProofs = 0
-		ns.halfOpen = false
+		if ns.failures() <= e.config.ProofGraceMisses {
+			ns.circuit = CircuitClosed
+		}
 	} else {
 		ns.missedProofs++
-		if ns.missedProofs > e.config.ProofGraceMisses {
-			ns.halfOpen = true
-		}
+		e.tripIfExceeded(ns)
+	}
+}
+
+// RecordIntegrityFailure records that the node served bytes that did not
+// hash to the requested CID. Integrity failures count toward
+// ProofGraceMisses and the half-open penalty alongside missed proofs, but
+// are kept separately: a passing proof doesn't clear them, only a
+// successful probe does.
+func (e *Engine) RecordIntegrityFailure(nodeID string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	ns := e.getOrCreate(nodeID)
+	if ns == nil {
+		return
 	}
+	ns.integrityFailures++
+	e.tripIfExceeded(ns)
 }
 
 // SetGeoLabel sets the geographic label for a node.
 func (e *Engine) SetGeoLabel(nodeID, label string) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
-	e.getOrCreate(nodeID).geoLabel = label
+	if ns := e.getOrCreate(nodeID); ns != nil {
+		ns.geoLabel = label
+	}
 }
 
 // Score computes the current score for a node given a preferred geo label.
@@ -124,45 +260,44 @@ func (e *Engine) Score(nodeID, preferredGeo string) NodeScore {
 	if !ok {
 		return NodeScore{NodeID: nodeID}
 	}
+	return e.scoreLocked(nodeID, ns, preferredGeo)
+}
 
+// scoreLocked computes a node's score with the configured Scorer. Caller
+// must hold e.mu.
+func (e *Engine) scoreLocked(nodeID string, ns *nodeState, preferredGeo string) NodeScore {
+	v := e.viewLocked(nodeID, ns, preferredGeo, e.now())
 	score := NodeScore{
-		NodeID:         nodeID,
-		SampleCount:    len(ns.latencies),
-		MissedProofs:   ns.missedProofs,
-		GeoLabel:       ns.geoLabel,
-		HalfOpen:       ns.halfOpen,
-		LastProofCheck: ns.lastProof,
+		NodeID:            nodeID,
+		SampleCount:       v.SampleCount,
+		Throughput:        v.Throughput,
+		TransferCount:     v.TransferCount,
+		ErrorRate:         v.ErrorRate,
+		ErrorCount:        v.ErrorCount,
+		MissedProofs:      v.MissedProofs,
+		IntegrityFailures: v.IntegrityFailures,
+		GeoLabel:          v.GeoLabel,
+		LastProofCheck:    v.LastProofCheck,
+		HalfOpen:          v.Circuit != CircuitClosed,
+		Circuit:           v.Circuit,
 	}
-
-	// Grace period: not enough samples yet.
-	if len(ns.latencies) < e.config.MinSamples {
-		score.Score = 0.5 // neutral
-		return score
-	}
-
-	score.P95Latency = p95(ns.latencies)
-
-	// Base latency score: lower is better. Normalize to 0-1 (cap at 10s).
-	latencyScore := 1.0 - float64(score.P95Latency)/float64(10*time.Second)
-	if latencyScore < 0 {
-		latencyScore = 0
-	}
-
-	score.Score = latencyScore * e.config.LatencyWeight
-
-	// Geo boost.
-	if ns.geoLabel == preferredGeo && preferredGeo != "" {
-		score.Score += e.config.GeoBoost
+	score.Score = scoreOnly(e.scorer(), v, e.config)
+
+	// Latency stats are only reported once out of the grace period.
+	if v.SampleCount >= e.config.MinSamples {
+		score.P50Latency = v.P50Latency
+		score.P95Latency = v.P95Latency
+		score.P99Latency = v.P99Latency
+		score.EWMALatency = v.EWMALatency
 	}
-
-	// Proof penalty.
-	if ns.missedProofs > e.config.ProofGraceMisses {
-		score.Score *= 0.5
-	}
-
 	return score
 }
 
+// failures is the count checked against ProofGraceMisses.
+func (ns *nodeState) failures() int {
+	return ns.missedProofs + ns.integrityFailures
+}
+
 // NeedsProofCheck returns true if the node's proof TTL has expired.
 func (e *Engine) NeedsProofCheck(nodeID string) bool {
 	e.mu.RLock()
@@ -172,35 +307,25 @@ func (e *Engine) NeedsProofCheck(nodeID string) bool {
 	if !ok {
 		return true
 	}
-	return time.Since(ns.lastProof) > e.config.ProofTTL
+	return e.now().Sub(ns.lastProof) > e.config.ProofTTL
 }
 
+// getOrCreate returns the state for nodeID, creating it on first sight, and
+// marks the node active. It returns nil if the sample must be dropped: the
+// node was deregistered (and its tombstone hasn't expired), or
+// RequireRegistration is set and the node isn't registered. Caller must
+// hold e.mu for writing.
 func (e *Engine) getOrCreate(nodeID string) *nodeState {
+	now := e.now()
 	ns, ok := e.nodes[nodeID]
 	if !ok {
-		ns = &nodeState{}
+		if e.tombstoned(nodeID, now) || e.config.RequireRegistration {
+			e.rejected++
+			return nil
+		}
+		ns = &nodeState{latency: e.newEstimator()}
 		e.nodes[nodeID] = ns
 	}
+	ns.lastSeen = now
 	return ns
 }
-
-// p95 computes the P95 latency from a slice of durations.
-func p95(latencies []time.Duration) time.Duration {
-	if len(latencies) == 0 {
-		return 0
-	}
-	// Simple: sort a copy and pick the 95th percentile index.
-	sorted := make([]time.Duration, len(latencies))
-	copy(sorted, latencies)
-	// Insertion sort (small N).
-	for i := 1; i < len(sorted); i++ {
-		for j := i; j > 0 && sorted[j] < sorted[j-1]; j-- {
-			sorted[j], sorted[j-1] = sorted[j-1], sorted[j]
-		}
-	}
-	idx := int(float64(len(sorted)) * 0.95)
-	if idx >= len(sorted) {
-		idx = len(sorted) - 1
-	}
-	return sorted[idx]
-}
@@ -0,0 +1,127 @@
+package policy
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// SelectRequest describes which nodes Engine.Select should return.
+type SelectRequest struct {
+	// PreferredGeo is passed through to scoring for the geo boost.
+	PreferredGeo string
+
+	// K caps the number of nodes returned. Zero returns every eligible node.
+	K int
+
+	// Candidates restricts selection to these node IDs. Empty means every
+	// node the engine knows about. Unknown candidates score zero.
+	Candidates []string
+
+	// Exclude removes nodes from consideration, e.g. ones that already
+	// failed for this request.
+	Exclude []string
+
+	// MinScore drops nodes scoring below this threshold.
+	MinScore float64
+
+	// Weighted orders nodes by score-weighted random sampling instead of
+	// strictly by score, spreading load across comparable nodes.
+	Weighted bool
+
+	// AllowHalfOpen includes nodes whose circuit is open or half-open. Set
+	// it for probe traffic or last-resort failover; by default degraded
+	// nodes are skipped.
+	AllowHalfOpen bool
+
+	// Rand is the randomness source for weighted selection. If nil the
+	// global math/rand source is used.
+	Rand *rand.Rand
+}
+
+// Select scores the eligible nodes and returns up to K of them, best first
+// (or in weighted-random order if Weighted is set). All nodes are scored
+// under a single read lock so the result is a consistent snapshot. Ties are
+// broken by node ID.
+func (e *Engine) Select(req SelectRequest) []NodeScore {
+	excluded := make(map[string]bool, len(req.Exclude))
+	for _, id := range req.Exclude {
+		excluded[id] = true
+	}
+
+	e.mu.RLock()
+	var scores []NodeScore
+	consider := func(id string) {
+		if excluded[id] {
+			return
+		}
+		ns, ok := e.nodes[id]
+		if !ok {
+			scores = append(scores, NodeScore{NodeID: id})
+			return
+		}
+		scores = append(scores, e.scoreLocked(id, ns, req.PreferredGeo))
+	}
+	if len(req.Candidates) > 0 {
+		seen := make(map[string]bool, len(req.Candidates))
+		for _, id := range req.Candidates {
+			if !seen[id] {
+				seen[id] = true
+				consider(id)
+			}
+		}
+	} else {
+		for id := range e.nodes {
+			consider(id)
+		}
+	}
+	e.mu.RUnlock()
+
+	eligible := scores[:0]
+	for _, s := range scores {
+		if s.HalfOpen && !req.AllowHalfOpen {
+			continue
+		}
+		if s.Score < req.MinScore {
+			continue
+		}
+		eligible = append(eligible, s)
+	}
+
+	sort.Slice(eligible, func(i, j int) bool {
+		if eligible[i].Score != eligible[j].Score {
+			return eligible[i].Score > eligible[j].Score
+		}
+		return eligible[i].NodeID < eligible[j].NodeID
+	})
+	if req.Weighted {
+		weightedShuffle(eligible, req.Rand)
+	}
+
+	if req.K > 0 && len(eligible) > req.K {
+		eligible = eligible[:req.K]
+	}
+	return eligible
+}
+
+// weightedShuffle reorders nodes by weighted random sampling without
+// replacement (Efraimidis–Spirakis): each node draws key u^(1/score) and
+// nodes are sorted by descending key. Zero-score nodes keep their relative
+// order at the end.
+func weightedShuffle(nodes []NodeScore, rng *rand.Rand) {
+	float := rand.Float64
+	if rng != nil {
+		float = rng.Float64
+	}
+	keys := make(map[string]float64, len(nodes))
+	for _, n := range nodes {
+		if n.Score <= 0 {
+			keys[n.NodeID] = -1
+			continue
+		}
+		keys[n.NodeID] = math.Pow(float(), 1/n.Score)
+	}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return keys[nodes[i].NodeID] > keys[nodes[j].NodeID]
+	})
+}
@@ -0,0 +1,100 @@
+package policy
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func seedNode(e *Engine, nodeID string, latency time.Duration) {
+	for i := 0; i < e.config.MinSamples; i++ {
+		e.RecordLatency(nodeID, latency)
+	}
+}
+
+func ids(scores []NodeScore) []string {
+	out := make([]string, len(scores))
+	for i, s := range scores {
+		out[i] = s.NodeID
+	}
+	return out
+}
+
+func TestSelect_TopK(t *testing.T) {
+	e, _ := newTestEngine()
+	seedNode(e, "fast", 10*time.Millisecond)
+	seedNode(e, "medium", time.Second)
+	seedNode(e, "slow", 5*time.Second)
+
+	got := ids(e.Select(SelectRequest{K: 2}))
+	if len(got) != 2 || got[0] != "fast" || got[1] != "medium" {
+		t.Fatalf("expected [fast medium], got %v", got)
+	}
+}
+
+func TestSelect_TieBreakByID(t *testing.T) {
+	e, _ := newTestEngine()
+	seedNode(e, "b", 10*time.Millisecond)
+	seedNode(e, "a", 10*time.Millisecond)
+
+	got := ids(e.Select(SelectRequest{}))
+	if got[0] != "a" || got[1] != "b" {
+		t.Fatalf("expected deterministic [a b], got %v", got)
+	}
+}
+
+func TestSelect_ExclusionsAndMinScore(t *testing.T) {
+	e, _ := newTestEngine()
+	seedNode(e, "fast", 10*time.Millisecond)
+	seedNode(e, "medium", time.Second)
+	seedNode(e, "slow", 9*time.Second)
+
+	got := ids(e.Select(SelectRequest{Exclude: []string{"fast"}, MinScore: 0.2}))
+	if len(got) != 1 || got[0] != "medium" {
+		t.Fatalf("expected [medium], got %v", got)
+	}
+}
+
+func TestSelect_SkipsHalfOpenUnlessProbe(t *testing.T) {
+	e, _ := newTestEngine()
+	seedNode(e, "healthy", time.Second)
+	seedNode(e, "tripped", 10*time.Millisecond)
+	trip(e, "tripped")
+
+	got := ids(e.Select(SelectRequest{}))
+	if len(got) != 1 || got[0] != "healthy" {
+		t.Fatalf("expected half-open node skipped, got %v", got)
+	}
+	got = ids(e.Select(SelectRequest{AllowHalfOpen: true}))
+	if len(got) != 2 {
+		t.Fatalf("expected half-open node included for probes, got %v", got)
+	}
+}
+
+func TestSelect_Candidates(t *testing.T) {
+	e, _ := newTestEngine()
+	seedNode(e, "a", 10*time.Millisecond)
+	seedNode(e, "b", 10*time.Millisecond)
+
+	got := e.Select(SelectRequest{Candidates: []string{"b", "unknown", "b"}})
+	if len(got) != 2 || got[0].NodeID != "b" || got[1].NodeID != "unknown" || got[1].Score != 0 {
+		t.Fatalf("expected [b unknown(0)], got %+v", got)
+	}
+}
+
+func TestSelect_WeightedSpreadsLoad(t *testing.T) {
+	e, _ := newTestEngine()
+	seedNode(e, "a", 100*time.Millisecond)
+	seedNode(e, "b", 200*time.Millisecond)
+
+	rng := rand.New(rand.NewSource(1))
+	firsts := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		got := e.Select(SelectRequest{K: 1, Weighted: true, Rand: rng})
+		firsts[got[0].NodeID]++
+	}
+	// Scores are nearly equal, so both nodes should win a fair share.
+	if firsts["a"] < 300 || firsts["b"] < 300 {
+		t.Fatalf("expected load spread across comparable nodes, got %v", firsts)
+	}
+}
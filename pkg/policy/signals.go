@@ -0,0 +1,148 @@
+package policy
+
+import "time"
+
+// ErrorKind classifies a failed request for RecordError.
+type ErrorKind string
+
+const (
+	// ErrorTimeout: the node did not respond within the attempt timeout.
+	ErrorTimeout ErrorKind = "timeout"
+
+	// ErrorUnavailable: the node refused the request or returned a server
+	// error.
+	ErrorUnavailable ErrorKind = "unavailable"
+
+	// ErrorStall: the node responded but the transfer failed or stalled
+	// mid-body.
+	ErrorStall ErrorKind = "stall"
+
+	// ErrorOther is any other node-attributable failure.
+	ErrorOther ErrorKind = "other"
+)
+
+// signalWindowSize caps the transfer and outcome windows, like the exact
+// latency window.
+const signalWindowSize = 100
+
+type transferSample struct {
+	bytes int64
+	d     time.Duration
+	at    time.Time
+}
+
+// outcomeSample is one request outcome; kind is empty for a success.
+type outcomeSample struct {
+	kind ErrorKind
+	at   time.Time
+}
+
+// RecordTransfer records that nodeID delivered bytes over d of transfer
+// time. It also counts as a successful request for the error rate.
+func (e *Engine) RecordTransfer(nodeID string, bytes int64, d time.Duration) {
+	e.recordTransfer(nodeID, bytes, d, true)
+}
+
+// RecordPartialTransfer records the throughput of a transfer that did not
+// complete, without counting it as a successful request. Pair it with
+// RecordError when the node was at fault.
+func (e *Engine) RecordPartialTransfer(nodeID string, bytes int64, d time.Duration) {
+	e.recordTransfer(nodeID, bytes, d, false)
+}
+
+func (e *Engine) recordTransfer(nodeID string, bytes int64, d time.Duration, success bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := e.now()
+	ns := e.getOrCreate(nodeID)
+	if ns == nil {
+		return
+	}
+	ns.transfers = trimWindow(append(ns.transfers, transferSample{bytes: bytes, d: d, at: now}),
+		func(s transferSample) time.Time { return s.at }, now, e.config.LatencyMaxAge)
+	if success {
+		ns.outcomes = trimWindow(append(ns.outcomes, outcomeSample{at: now}),
+			func(s outcomeSample) time.Time { return s.at }, now, e.config.LatencyMaxAge)
+	}
+}
+
+// RecordError records a failed request to nodeID.
+func (e *Engine) RecordError(nodeID string, kind ErrorKind) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if kind == "" {
+		kind = ErrorOther
+	}
+	now := e.now()
+	ns := e.getOrCreate(nodeID)
+	if ns == nil {
+		return
+	}
+	ns.outcomes = trimWindow(append(ns.outcomes, outcomeSample{kind: kind, at: now}),
+		func(s outcomeSample) time.Time { return s.at }, now, e.config.LatencyMaxAge)
+}
+
+// throughput returns aggregate bytes/second over the live transfer window
+// and the number of transfers in it. It does not modify ns.
+func (ns *nodeState) throughput(now time.Time, maxAge time.Duration) (float64, int) {
+	live := ns.transfers[firstLive(ns.transfers, func(s transferSample) time.Time { return s.at }, now, maxAge):]
+	var bytes int64
+	var d time.Duration
+	for _, s := range live {
+		bytes += s.bytes
+		d += s.d
+	}
+	if d <= 0 {
+		return 0, len(live)
+	}
+	return float64(bytes) / d.Seconds(), len(live)
+}
+
+// errorRate returns the fraction of live outcomes that were errors, the
+// error count, and the count per kind (nil if none). It does not modify ns.
+func (ns *nodeState) errorRate(now time.Time, maxAge time.Duration) (float64, int, map[ErrorKind]int) {
+	live := ns.outcomes[firstLive(ns.outcomes, func(s outcomeSample) time.Time { return s.at }, now, maxAge):]
+	var byKind map[ErrorKind]int
+	errs := 0
+	for _, s := range live {
+		if s.kind == "" {
+			continue
+		}
+		if byKind == nil {
+			byKind = make(map[ErrorKind]int)
+		}
+		byKind[s.kind]++
+		errs++
+	}
+	if len(live) == 0 {
+		return 0, 0, nil
+	}
+	return float64(errs) / float64(len(live)), errs, byKind
+}
+
+// trimWindow caps s at signalWindowSize and drops entries older than maxAge.
+func trimWindow[S any](s []S, at func(S) time.Time, now time.Time, maxAge time.Duration) []S {
+	if len(s) > signalWindowSize {
+		s = s[len(s)-signalWindowSize:]
+	}
+	if i := firstLive(s, at, now, maxAge); i > 0 {
+		s = append(s[:0:0], s[i:]...)
+	}
+	return s
+}
+
+// firstLive returns the index of the oldest entry within maxAge of now.
+// Entries are oldest first; a zero maxAge keeps everything.
+func firstLive[S any](s []S, at func(S) time.Time, now time.Time, maxAge time.Duration) int {
+	if maxAge <= 0 {
+		return 0
+	}
+	cutoff := now.Add(-maxAge)
+	i := 0
+	for i < len(s) && at(s[i]).Before(cutoff) {
+		i++
+	}
+	return i
+}
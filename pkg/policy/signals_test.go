@@ -0,0 +1,85 @@
+package policy
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSignals_ThroughputAggregate(t *testing.T) {
+	e, _ := newTestEngine()
+	e.RecordTransfer("node-1", 1<<20, time.Second)
+	e.RecordTransfer("node-1", 3<<20, time.Second)
+
+	s := e.Score("node-1", "")
+	if s.Throughput != 2<<20 || s.TransferCount != 2 {
+		t.Fatalf("expected 2 MiB/s over 2 transfers, got %v over %d", s.Throughput, s.TransferCount)
+	}
+	if s.ErrorRate != 0 || s.ErrorCount != 0 {
+		t.Fatalf("expected no errors, got %+v", s)
+	}
+}
+
+func TestSignals_ErrorRatePenalty(t *testing.T) {
+	e, _ := newTestEngine()
+	seedNode(e, "node-1", 100*time.Millisecond)
+	clean := e.Score("node-1", "").Score
+
+	e.RecordTransfer("node-1", 1<<20, time.Second)
+	e.RecordError("node-1", ErrorTimeout)
+	e.RecordError("node-1", "")
+
+	s := e.Score("node-1", "")
+	if math.Abs(s.ErrorRate-2.0/3) > 1e-9 || s.ErrorCount != 2 {
+		t.Fatalf("expected error rate 2/3, got %v (%d errors)", s.ErrorRate, s.ErrorCount)
+	}
+	if s.Score >= clean {
+		t.Fatalf("expected error penalty: clean=%v now=%v", clean, s.Score)
+	}
+
+	x := e.Explain("node-1", "")
+	if math.Abs(x.ErrorPenalty-(1-0.5*2.0/3)) > 1e-9 {
+		t.Fatalf("unexpected error penalty %v", x.ErrorPenalty)
+	}
+	if x.Inputs.ErrorsByKind[ErrorTimeout] != 1 || x.Inputs.ErrorsByKind[ErrorOther] != 1 {
+		t.Fatalf("unexpected error kinds %v", x.Inputs.ErrorsByKind)
+	}
+}
+
+func TestSignals_StallingNodeLosesSelection(t *testing.T) {
+	e, _ := newTestEngine()
+
+	// "fast" answers quickly but stalls mid-segment; "steady" is slower to
+	// first byte but streams at full rate.
+	seedNode(e, "fast", 20*time.Millisecond)
+	seedNode(e, "steady", 300*time.Millisecond)
+	for i := 0; i < 5; i++ {
+		e.RecordTransfer("fast", 64<<10, 2*time.Second)
+		e.RecordError("fast", ErrorStall)
+		e.RecordTransfer("steady", 8<<20, time.Second)
+	}
+
+	got := ids(e.Select(SelectRequest{K: 2}))
+	if got[0] != "steady" {
+		t.Fatalf("expected steady node first, got %v", got)
+	}
+}
+
+func TestSignals_WindowsExpire(t *testing.T) {
+	e, clock := newTestEngine()
+	e.RecordTransfer("node-1", 1<<20, time.Second)
+	e.RecordError("node-1", ErrorUnavailable)
+
+	clock.Advance(31 * time.Minute)
+	s := e.Score("node-1", "")
+	if s.TransferCount != 0 || s.ErrorCount != 0 || s.ErrorRate != 0 {
+		t.Fatalf("expected expired windows, got %+v", s)
+	}
+
+	for i := 0; i < 150; i++ {
+		e.RecordError("node-1", ErrorUnavailable)
+	}
+	if n := len(e.nodes["node-1"].outcomes); n != signalWindowSize {
+		t.Fatalf("expected window capped at %d, got %d", signalWindowSize, n)
+	}
+}
@@ -0,0 +1,287 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SnapshotVersion is the current snapshot format version.
+//
+//	1: latencies as bare durations
+//	2: timestamped latency samples and EWMA state
+//	3: opaque estimator state for non-exact quantile backends
+//	4: transfer and error windows
+//	5: registration, activity times and tombstones
+const SnapshotVersion = 5
+
+// ErrSnapshotVersion is returned by Restore for snapshots written in a
+// format this engine does not understand.
+var ErrSnapshotVersion = errors.New("policy: unsupported snapshot version")
+
+type snapshot struct {
+	Version    int                     `json:"version"`
+	TakenAt    time.Time               `json:"taken_at"`
+	Nodes      map[string]nodeSnapshot `json:"nodes"`
+	Tombstones map[string]time.Time    `json:"tombstones,omitempty"`
+}
+
+type nodeSnapshot struct {
+	Latencies         []time.Duration    `json:"latencies_ns,omitempty"` // version 1 only
+	Samples           []sampleSnapshot   `json:"samples,omitempty"`
+	Estimator         json.RawMessage    `json:"estimator,omitempty"`
+	Transfers         []transferSnapshot `json:"transfers,omitempty"`
+	Outcomes          []outcomeSnapshot  `json:"outcomes,omitempty"`
+	EWMA              time.Duration      `json:"ewma_ns,omitempty"`
+	EWMAWeight        float64            `json:"ewma_weight,omitempty"`
+	EWMAAt            time.Time          `json:"ewma_at,omitempty"`
+	MissedProofs      int                `json:"missed_proofs"`
+	IntegrityFailures int                `json:"integrity_failures"`
+	GeoLabel          string             `json:"geo_label,omitempty"`
+	LastProof         time.Time          `json:"last_proof"`
+	Circuit           CircuitState       `json:"circuit"`
+	LastProbe         time.Time          `json:"last_probe"`
+	Registered        bool               `json:"registered,omitempty"`
+	RegisteredAt      time.Time          `json:"registered_at,omitempty"`
+	LastSeen          time.Time          `json:"last_seen,omitempty"`
+}
+
+type sampleSnapshot struct {
+	Latency time.Duration `json:"ns"`
+	At      time.Time     `json:"at"`
+}
+
+type transferSnapshot struct {
+	Bytes    int64         `json:"bytes"`
+	Duration time.Duration `json:"ns"`
+	At       time.Time     `json:"at"`
+}
+
+type outcomeSnapshot struct {
+	Error ErrorKind `json:"error,omitempty"`
+	At    time.Time `json:"at"`
+}
+
+// Snapshot writes the engine's node state (latency, transfer and error
+// windows, proof and integrity counters, geo labels and circuit state) to w as versioned JSON.
+// Config is not included; it comes from the caller on restart.
+func (e *Engine) Snapshot(w io.Writer) error {
+	e.mu.RLock()
+	snap := snapshot{
+		Version: SnapshotVersion,
+		TakenAt: e.now(),
+		Nodes:   make(map[string]nodeSnapshot, len(e.nodes)),
+	}
+	if len(e.tombstones) > 0 {
+		snap.Tombstones = make(map[string]time.Time, len(e.tombstones))
+		for id, at := range e.tombstones {
+			snap.Tombstones[id] = at
+		}
+	}
+	for id, ns := range e.nodes {
+		var samples []sampleSnapshot
+		var est json.RawMessage
+		switch l := ns.latency.(type) {
+		case *ExactEstimator:
+			for _, s := range l.latencySamples() {
+				samples = append(samples, sampleSnapshot{Latency: s.d, At: s.at})
+			}
+		case json.Marshaler:
+			b, err := l.MarshalJSON()
+			if err != nil {
+				e.mu.RUnlock()
+				return fmt.Errorf("policy: encode estimator for %s: %w", id, err)
+			}
+			est = b
+		}
+		transfers := make([]transferSnapshot, len(ns.transfers))
+		for i, t := range ns.transfers {
+			transfers[i] = transferSnapshot{Bytes: t.bytes, Duration: t.d, At: t.at}
+		}
+		outcomes := make([]outcomeSnapshot, len(ns.outcomes))
+		for i, o := range ns.outcomes {
+			outcomes[i] = outcomeSnapshot{Error: o.kind, At: o.at}
+		}
+		snap.Nodes[id] = nodeSnapshot{
+			Samples:           samples,
+			Estimator:         est,
+			Transfers:         transfers,
+			Outcomes:          outcomes,
+			EWMA:              ns.ewma,
+			EWMAWeight:        ns.ewmaWeight,
+			EWMAAt:            ns.ewmaAt,
+			MissedProofs:      ns.missedProofs,
+			IntegrityFailures: ns.integrityFailures,
+			GeoLabel:          ns.geoLabel,
+			LastProof:         ns.lastProof,
+			Circuit:           ns.circuit,
+			LastProbe:         ns.lastProbe,
+			Registered:        ns.registered,
+			RegisteredAt:      ns.registeredAt,
+			LastSeen:          ns.lastSeen,
+		}
+	}
+	e.mu.RUnlock()
+
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(snap); err != nil {
+		return fmt.Errorf("policy: encode snapshot: %w", err)
+	}
+	return nil
+}
+
+// Restore replaces the engine's node state with a snapshot written by
+// Snapshot. Version 1 snapshots are accepted; their latency samples are
+// stamped with the snapshot time, as is the activity time of nodes from
+// snapshots before version 5. Saved samples are replayed into the
+// configured estimator; estimator state that doesn't fit the configured
+// backend (e.g. after switching backends) is dropped and that node starts
+// with an empty latency window. On error the existing state is left
+// untouched.
+func (e *Engine) Restore(r io.Reader) error {
+	var snap snapshot
+	if err := json.NewDecoder(r).Decode(&snap); err != nil {
+		return fmt.Errorf("policy: decode snapshot: %w", err)
+	}
+	if snap.Version < 1 || snap.Version > SnapshotVersion {
+		return fmt.Errorf("%w: %d", ErrSnapshotVersion, snap.Version)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	nodes := make(map[string]*nodeState, len(snap.Nodes))
+	for id, n := range snap.Nodes {
+		est := e.newEstimator()
+		if u, ok := est.(json.Unmarshaler); ok && len(n.Estimator) > 0 {
+			if err := u.UnmarshalJSON(n.Estimator); err != nil {
+				est = e.newEstimator()
+			}
+		}
+		for _, d := range n.Latencies {
+			est.Add(d, snap.TakenAt)
+		}
+		for _, s := range n.Samples {
+			est.Add(s.Latency, s.At)
+		}
+		var transfers []transferSample
+		for _, t := range n.Transfers {
+			transfers = append(transfers, transferSample{bytes: t.Bytes, d: t.Duration, at: t.At})
+		}
+		var outcomes []outcomeSample
+		for _, o := range n.Outcomes {
+			outcomes = append(outcomes, outcomeSample{kind: o.Error, at: o.At})
+		}
+		nodes[id] = &nodeState{
+			latency:           est,
+			transfers:         transfers,
+			outcomes:          outcomes,
+			ewma:              n.EWMA,
+			ewmaWeight:        n.EWMAWeight,
+			ewmaAt:            n.EWMAAt,
+			missedProofs:      n.MissedProofs,
+			integrityFailures: n.IntegrityFailures,
+			geoLabel:          n.GeoLabel,
+			lastProof:         n.LastProof,
+			circuit:           n.Circuit,
+			lastProbe:         n.LastProbe,
+			registered:        n.Registered,
+			registeredAt:      n.RegisteredAt,
+			lastSeen:          n.LastSeen,
+		}
+		if n.LastSeen.IsZero() {
+			nodes[id].lastSeen = snap.TakenAt
+		}
+	}
+	tombstones := make(map[string]time.Time, len(snap.Tombstones))
+	for id, at := range snap.Tombstones {
+		tombstones[id] = at
+	}
+	e.nodes = nodes
+	e.tombstones = tombstones
+	return nil
+}
+
+// SnapshotToFile writes a snapshot to path atomically (temp file + rename),
+// so a crash mid-write never leaves a truncated snapshot behind.
+func (e *Engine) SnapshotToFile(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("policy: create snapshot: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := e.Snapshot(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("policy: sync snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("policy: close snapshot: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("policy: install snapshot: %w", err)
+	}
+	return nil
+}
+
+// RestoreFromFile restores from a snapshot file. A missing file returns an
+// error matching fs.ErrNotExist, which callers may treat as a fresh start.
+func (e *Engine) RestoreFromFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("policy: open snapshot: %w", err)
+	}
+	defer f.Close()
+	return e.Restore(f)
+}
+
+// RunSnapshotter writes a snapshot to path every interval until ctx is
+// cancelled, then writes a final snapshot so state from the last interval
+// survives a graceful shutdown. Write errors are passed to onError (if not
+// nil) and do not stop the loop.
+func (e *Engine) RunSnapshotter(ctx context.Context, path string, interval time.Duration, onError func(error)) error {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			if err := e.SnapshotToFile(path); err != nil && onError != nil {
+				onError(err)
+			}
+		case <-ctx.Done():
+			if err := e.SnapshotToFile(path); err != nil {
+				return err
+			}
+			return ctx.Err()
+		}
+	}
+}
+
+// MarshalText encodes the state by name for snapshots and JSON output.
+func (s CircuitState) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes a state name written by MarshalText.
+func (s *CircuitState) UnmarshalText(b []byte) error {
+	switch string(b) {
+	case "closed":
+		*s = CircuitClosed
+	case "open":
+		*s = CircuitOpen
+	case "half-open":
+		*s = CircuitHalfOpen
+	default:
+		return fmt.Errorf("policy: unknown circuit state %q", b)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package policy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSnapshot_RoundTrip(t *testing.T) {
+	e, clock := newTestEngine()
+	seedNode(e, "node-1", 20*time.Millisecond)
+	e.SetGeoLabel("node-1", "us-east")
+	e.RecordProofResult("node-1", true)
+	trip(e, "node-2")
+	e.RecordIntegrityFailure("node-2")
+	e.RecordTransfer("node-1", 4<<20, time.Second)
+	e.RecordError("node-1", ErrorStall)
+
+	var buf bytes.Buffer
+	if err := e.Snapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := NewEngine(DefaultConfig())
+	restored.SetClock(clock.Now)
+	if err := restored.Restore(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{"node-1", "node-2"} {
+		want, got := e.Score(id, "us-east"), restored.Score(id, "us-east")
+		if want != got {
+			t.Fatalf("%s: restored score %+v, want %+v", id, got, want)
+		}
+	}
+	if restored.NeedsProofCheck("node-1") {
+		t.Fatal("expected proof timestamp restored")
+	}
+}
+
+func TestSnapshot_RejectsUnknownVersion(t *testing.T) {
+	e := NewEngine(DefaultConfig())
+	seedNode(e, "node-1", time.Millisecond)
+
+	err := e.Restore(strings.NewReader(`{"version": 99, "nodes": {}}`))
+	if !errors.Is(err, ErrSnapshotVersion) {
+		t.Fatalf("expected ErrSnapshotVersion, got %v", err)
+	}
+	if e.Score("node-1", "").SampleCount == 0 {
+		t.Fatal("failed restore must not clobber existing state")
+	}
+}
+
+func TestSnapshot_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "engine.json")
+	e := NewEngine(DefaultConfig())
+
+	if err := e.RestoreFromFile(path); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected fs.ErrNotExist for missing snapshot, got %v", err)
+	}
+
+	seedNode(e, "node-1", time.Millisecond)
+	if err := e.SnapshotToFile(path); err != nil {
+		t.Fatal(err)
+	}
+	restored := NewEngine(DefaultConfig())
+	if err := restored.RestoreFromFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if got := restored.Score("node-1", "").SampleCount; got != 10 {
+		t.Fatalf("expected 10 samples restored, got %d", got)
+	}
+}
+
+func TestSnapshot_RunSnapshotterWritesOnShutdown(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "engine.json")
+	e := NewEngine(DefaultConfig())
+	seedNode(e, "node-1", time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := e.RunSnapshotter(ctx, path, time.Hour, nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	restored := NewEngine(DefaultConfig())
+	if err := restored.RestoreFromFile(path); err != nil {
+		t.Fatalf("expected final snapshot on shutdown: %v", err)
+	}
+}
@@ -0,0 +1,312 @@
+package routing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/quriustus/filstream-curio-adapter/pkg/adapter"
+	"github.com/quriustus/filstream-curio-adapter/pkg/policy"
+)
+
+// HedgeConfig controls speculative range requests.
+type HedgeConfig struct {
+	// DelayMultiplier scales the primary node's P95 latency to get the hedge
+	// delay. 1.0 hedges exactly at P95.
+	DelayMultiplier float64
+
+	// MinDelay is the lower bound on the hedge delay.
+	MinDelay time.Duration
+
+	// DefaultDelay is used when the primary node has no P95 yet (grace period).
+	DefaultDelay time.Duration
+
+	// MaxHedgeRatio caps hedged requests as a fraction of all requests (0-1),
+	// so hedging cannot double load on the network.
+	MaxHedgeRatio float64
+
+	// HedgeBurst is how many hedges may be issued back-to-back before the
+	// ratio limit applies.
+	HedgeBurst float64
+}
+
+// DefaultHedgeConfig returns a HedgeConfig with sensible defaults: hedge at
+// P95, never sooner than 10ms, and at most 10% of requests.
+func DefaultHedgeConfig() HedgeConfig {
+	return HedgeConfig{
+		DelayMultiplier: 1.0,
+		MinDelay:        10 * time.Millisecond,
+		DefaultDelay:    500 * time.Millisecond,
+		MaxHedgeRatio:   0.1,
+		HedgeBurst:      1,
+	}
+}
+
+// HedgeResult is the outcome of a hedged range request.
+type HedgeResult struct {
+	Body   io.ReadCloser
+	NodeID string // replica that produced the first byte
+	Hedged bool   // a second request was issued
+}
+
+// HedgeStats are cumulative counters for a HedgedRetriever.
+type HedgeStats struct {
+	Requests  uint64
+	Hedged    uint64
+	HedgeWins uint64 // hedged requests won by the second replica
+}
+
+// HedgedRetriever issues a second GetRange to the next-best node when the
+// best node has not produced its first byte within a delay derived from its
+// P95 latency. The slower request is cancelled through its context.
+// Get is not hedged and is delegated to the underlying RoutedRetriever.
+type HedgedRetriever struct {
+	routed *RoutedRetriever
+	config HedgeConfig
+
+	mu     sync.Mutex
+	tokens float64
+	stats  HedgeStats
+}
+
+// NewHedgedRetriever creates a hedging retriever over the nodes and scores
+// of routed.
+func NewHedgedRetriever(routed *RoutedRetriever, cfg HedgeConfig) *HedgedRetriever {
+	return &HedgedRetriever{
+		routed: routed,
+		config: cfg,
+		tokens: cfg.HedgeBurst,
+	}
+}
+
+// Get retrieves the full content for cid without hedging.
+func (h *HedgedRetriever) Get(ctx context.Context, cid string) (io.ReadCloser, error) {
+	return h.routed.Get(ctx, cid)
+}
+
+// GetRange retrieves [start, end) for cid, hedging if the primary is slow.
+func (h *HedgedRetriever) GetRange(ctx context.Context, cid string, start, end uint64) (io.ReadCloser, error) {
+	res, err := h.GetRangeHedged(ctx, cid, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return res.Body, nil
+}
+
+// Stat delegates to the underlying RoutedRetriever.
+func (h *HedgedRetriever) Stat(ctx context.Context, cid string) (adapter.ObjectInfo, error) {
+	return h.routed.Stat(ctx, cid)
+}
+
+// Stats returns a snapshot of the hedging counters.
+func (h *HedgedRetriever) Stats() HedgeStats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.stats
+}
+
+type firstByteResult struct {
+	nodeID string
+	body   io.ReadCloser
+	ttfb   time.Duration
+	err    error
+}
+
+// GetRangeHedged is GetRange that also reports which replica won.
+func (h *HedgedRetriever) GetRangeHedged(ctx context.Context, cid string, start, end uint64) (HedgeResult, error) {
+	ranked := h.routed.Rank()
+	if len(ranked) == 0 {
+		return HedgeResult{}, ErrNoNodes
+	}
+	h.mu.Lock()
+	h.stats.Requests++
+	h.tokens += h.config.MaxHedgeRatio
+	if h.tokens > h.config.HedgeBurst {
+		h.tokens = h.config.HedgeBurst
+	}
+	h.mu.Unlock()
+
+	results := make(chan firstByteResult, len(ranked))
+	cancels := make(map[string]context.CancelFunc)
+	next := 0
+	// pick returns the index of the next ranked node still registered
+	// (skipping removed ones) without launching it, or -1.
+	pick := func() (int, adapter.RetrieverAPI) {
+		for ; next < len(ranked); next++ {
+			h.routed.mu.RLock()
+			rt, ok := h.routed.nodes[ranked[next].NodeID]
+			h.routed.mu.RUnlock()
+			if ok {
+				return next, rt
+			}
+		}
+		return -1, nil
+	}
+	launchAt := func(i int, rt adapter.RetrieverAPI) {
+		next = i + 1
+		nodeID := ranked[i].NodeID
+		actx, cancel := context.WithCancel(ctx)
+		cancels[nodeID] = cancel
+		go fetchFirstByte(actx, nodeID, rt, cid, start, end, results)
+	}
+	launch := func() bool {
+		i, rt := pick()
+		if i < 0 {
+			return false
+		}
+		launchAt(i, rt)
+		return true
+	}
+
+	first, rt := pick()
+	if first < 0 {
+		return HedgeResult{}, ErrNoNodes
+	}
+	launchAt(first, rt)
+	pending := 1
+	primary := ranked[first].NodeID
+
+	var hedgeC <-chan time.Time
+	if next < len(ranked) {
+		timer := time.NewTimer(h.hedgeDelay(ranked[first]))
+		defer timer.Stop()
+		hedgeC = timer.C
+	}
+
+	hedged := false
+	errs := []error{ErrAllNodesFailed}
+	for pending > 0 {
+		select {
+		case <-hedgeC:
+			hedgeC = nil
+			// Only spend a token when there is a node to hedge to.
+			if i, rt := pick(); i >= 0 && h.allowHedge() {
+				launchAt(i, rt)
+				hedged = true
+				pending++
+			}
+
+		case res := <-results:
+			pending--
+			if res.err != nil {
+				cancels[res.nodeID]()
+				h.routed.recordFailure(ctx, res.nodeID, res.err)
+				errs = append(errs, fmt.Errorf("node %s: %w", res.nodeID, res.err))
+				// Fail over immediately rather than waiting for the timer.
+				if pending == 0 && launch() {
+					pending++
+				}
+				continue
+			}
+
+			for id, cancel := range cancels {
+				if id != res.nodeID {
+					cancel()
+				}
+			}
+			go closeLosers(results, pending)
+
+			h.routed.engine.RecordLatency(res.nodeID, res.ttfb)
+			if hedged {
+				h.mu.Lock()
+				h.stats.Hedged++
+				if res.nodeID != primary {
+					h.stats.HedgeWins++
+				}
+				h.mu.Unlock()
+			}
+			return HedgeResult{
+				Body:   h.routed.meter(res.nodeID, res.body, cancels[res.nodeID]),
+				NodeID: res.nodeID,
+				Hedged: hedged,
+			}, nil
+
+		case <-ctx.Done():
+			for _, cancel := range cancels {
+				cancel()
+			}
+			go closeLosers(results, pending)
+			return HedgeResult{}, ctx.Err()
+		}
+	}
+
+	if hedged {
+		h.mu.Lock()
+		h.stats.Hedged++
+		h.mu.Unlock()
+	}
+	return HedgeResult{}, errors.Join(errs...)
+}
+
+// hedgeDelay derives the hedge delay from the primary node's P95 latency.
+func (h *HedgedRetriever) hedgeDelay(ns policy.NodeScore) time.Duration {
+	d := h.config.DefaultDelay
+	if ns.P95Latency > 0 {
+		d = time.Duration(float64(ns.P95Latency) * h.config.DelayMultiplier)
+	}
+	if d < h.config.MinDelay {
+		d = h.config.MinDelay
+	}
+	return d
+}
+
+// allowHedge consumes one hedge token if available.
+func (h *HedgedRetriever) allowHedge() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.tokens < 1 {
+		return false
+	}
+	h.tokens--
+	return true
+}
+
+// fetchFirstByte issues the range request and blocks until the first byte
+// arrives, so "winning" means actually delivering data.
+func fetchFirstByte(ctx context.Context, nodeID string, rt adapter.RetrieverAPI, cid string, start, end uint64, out chan<- firstByteResult) {
+	began := time.Now()
+	rc, err := rt.GetRange(ctx, cid, start, end)
+	if err != nil {
+		out <- firstByteResult{nodeID: nodeID, err: err}
+		return
+	}
+	first := make([]byte, 1)
+	if _, err := io.ReadFull(rc, first); err != nil {
+		rc.Close()
+		out <- firstByteResult{nodeID: nodeID, err: err}
+		return
+	}
+	out <- firstByteResult{
+		nodeID: nodeID,
+		body: &prefixedReadCloser{
+			Reader: io.MultiReader(bytes.NewReader(first), rc),
+			Closer: rc,
+		},
+		ttfb: time.Since(began),
+	}
+}
+
+// closeLosers drains the remaining in-flight attempts and closes any body
+// that arrived after the race was decided.
+func closeLosers(results <-chan firstByteResult, pending int) {
+	for ; pending > 0; pending-- {
+		if res := <-results; res.body != nil {
+			res.body.Close()
+		}
+	}
+}
+
+type prefixedReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// Compile-time interface checks.
+var (
+	_ adapter.RetrieverAPI = (*HedgedRetriever)(nil)
+	_ adapter.Stater       = (*HedgedRetriever)(nil)
+)
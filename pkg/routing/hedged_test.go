@@ -0,0 +1,152 @@
+package routing
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quriustus/filstream-curio-adapter/pkg/policy"
+)
+
+// slowRetriever delays its response and signals when its context is cancelled.
+type slowRetriever struct {
+	data      string
+	delay     time.Duration
+	cancelled chan struct{}
+}
+
+func newSlowRetriever(data string, delay time.Duration) *slowRetriever {
+	return &slowRetriever{data: data, delay: delay, cancelled: make(chan struct{}, 1)}
+}
+
+func (s *slowRetriever) Get(ctx context.Context, cid string) (io.ReadCloser, error) {
+	return s.GetRange(ctx, cid, 0, uint64(len(s.data)))
+}
+
+func (s *slowRetriever) GetRange(ctx context.Context, cid string, start, end uint64) (io.ReadCloser, error) {
+	select {
+	case <-time.After(s.delay):
+		return io.NopCloser(strings.NewReader(s.data[start:end])), nil
+	case <-ctx.Done():
+		s.cancelled <- struct{}{}
+		return nil, ctx.Err()
+	}
+}
+
+func newHedgeFixture(primaryDelay time.Duration, cfg HedgeConfig) (*HedgedRetriever, *slowRetriever, *slowRetriever, *policy.Engine) {
+	eng := policy.NewEngine(policy.DefaultConfig())
+	seedLatency(eng, "primary", 20*time.Millisecond)
+	seedLatency(eng, "secondary", 40*time.Millisecond)
+
+	primary := newSlowRetriever("primary-data", primaryDelay)
+	secondary := newSlowRetriever("second-data", 0)
+	routed := NewRoutedRetriever(eng, Config{})
+	routed.AddNode("primary", primary)
+	routed.AddNode("secondary", secondary)
+	return NewHedgedRetriever(routed, cfg), primary, secondary, eng
+}
+
+func TestHedgedRetriever_NoHedgeWhenPrimaryFast(t *testing.T) {
+	h, _, _, _ := newHedgeFixture(0, DefaultHedgeConfig())
+
+	res, err := h.GetRangeHedged(context.Background(), "cid", 0, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if res.NodeID != "primary" || res.Hedged {
+		t.Fatalf("expected unhedged primary win, got %+v", res)
+	}
+	data, _ := io.ReadAll(res.Body)
+	if string(data) != "primary" {
+		t.Fatalf("unexpected data: %q", data)
+	}
+}
+
+func TestHedgedRetriever_HedgeWinsAndCancelsLoser(t *testing.T) {
+	h, primary, _, eng := newHedgeFixture(2*time.Second, DefaultHedgeConfig())
+
+	res, err := h.GetRangeHedged(context.Background(), "cid", 0, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if res.NodeID != "secondary" || !res.Hedged {
+		t.Fatalf("expected hedged secondary win, got %+v", res)
+	}
+	data, _ := io.ReadAll(res.Body)
+	if string(data) != "second" {
+		t.Fatalf("unexpected data: %q", data)
+	}
+
+	select {
+	case <-primary.cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("expected losing primary request to be cancelled")
+	}
+
+	stats := h.Stats()
+	if stats.Requests != 1 || stats.Hedged != 1 || stats.HedgeWins != 1 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	if got := eng.Score("secondary", "").SampleCount; got != 16 {
+		t.Fatalf("expected winner latency recorded, got %d samples", got)
+	}
+}
+
+func TestHedgedRetriever_RateCap(t *testing.T) {
+	cfg := DefaultHedgeConfig()
+	cfg.MaxHedgeRatio = 0
+	cfg.HedgeBurst = 0
+	h, _, _, _ := newHedgeFixture(50*time.Millisecond, cfg)
+
+	res, err := h.GetRangeHedged(context.Background(), "cid", 0, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if res.Hedged || res.NodeID != "primary" {
+		t.Fatalf("expected hedging suppressed by rate cap, got %+v", res)
+	}
+}
+
+func TestHedgedRetriever_HedgeDelayFromP95(t *testing.T) {
+	h := NewHedgedRetriever(nil, DefaultHedgeConfig())
+
+	if d := h.hedgeDelay(policy.NodeScore{P95Latency: 80 * time.Millisecond}); d != 80*time.Millisecond {
+		t.Fatalf("expected P95-derived delay, got %v", d)
+	}
+	if d := h.hedgeDelay(policy.NodeScore{P95Latency: time.Millisecond}); d != 10*time.Millisecond {
+		t.Fatalf("expected MinDelay floor, got %v", d)
+	}
+	if d := h.hedgeDelay(policy.NodeScore{}); d != 500*time.Millisecond {
+		t.Fatalf("expected DefaultDelay during grace, got %v", d)
+	}
+}
+
+func TestHedgedRetriever_NoTokenSpentWithoutHedgeTarget(t *testing.T) {
+	cfg := DefaultHedgeConfig()
+	cfg.MinDelay = 100 * time.Millisecond
+	h, _, _, _ := newHedgeFixture(300*time.Millisecond, cfg)
+
+	// The only hedge target goes away before the hedge timer fires.
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		h.routed.RemoveNode("secondary")
+	}()
+	res, err := h.GetRangeHedged(context.Background(), "cid", 0, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.Hedged {
+		t.Fatalf("expected no hedge, got %+v", res)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.tokens != cfg.HedgeBurst {
+		t.Fatalf("expected hedge token kept, have %v", h.tokens)
+	}
+}
@@ -0,0 +1,310 @@
+// Package routing selects which Curio node serves a retrieval, using the
+// scores computed by policy.Engine.
+package routing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/quriustus/filstream-curio-adapter/pkg/adapter"
+	"github.com/quriustus/filstream-curio-adapter/pkg/policy"
+)
+
+// ErrNoNodes is returned when no retrievers are registered.
+var ErrNoNodes = errors.New("routing: no nodes registered")
+
+// ErrAllNodesFailed is returned (joined with each node's error) when every
+// candidate node failed to serve the request.
+var ErrAllNodesFailed = errors.New("routing: all nodes failed")
+
+// Config controls how RoutedRetriever picks and fails over between nodes.
+type Config struct {
+	// PreferredGeo is passed to Engine.Score so geo-matching nodes rank higher.
+	PreferredGeo string
+
+	// AttemptTimeout bounds how long a single node may take to return a
+	// response before the next node is tried. Zero means no per-node limit.
+	AttemptTimeout time.Duration
+}
+
+// RoutedRetriever implements adapter.RetrieverAPI over a set of per-node
+// retrievers. Nodes are tried in descending Engine.Score order; errors and
+// timeouts fall through to the next node. Observed time-to-response is fed
+// back through Engine.RecordLatency, failures through Engine.RecordError and
+// completed bodies through Engine.RecordTransfer.
+type RoutedRetriever struct {
+	engine *policy.Engine
+	config Config
+
+	mu    sync.RWMutex
+	nodes map[string]adapter.RetrieverAPI
+}
+
+// NewRoutedRetriever creates a retriever ranked by engine.
+func NewRoutedRetriever(engine *policy.Engine, cfg Config) *RoutedRetriever {
+	return &RoutedRetriever{
+		engine: engine,
+		config: cfg,
+		nodes:  make(map[string]adapter.RetrieverAPI),
+	}
+}
+
+// AddNode registers (or replaces) the retriever for nodeID and registers
+// the node with the engine.
+func (r *RoutedRetriever) AddNode(nodeID string, rt adapter.RetrieverAPI) {
+	r.mu.Lock()
+	r.nodes[nodeID] = rt
+	r.mu.Unlock()
+	r.engine.RegisterNode(nodeID)
+}
+
+// RemoveNode unregisters nodeID and deregisters it from the engine, so
+// late results from in-flight requests don't recreate its state.
+func (r *RoutedRetriever) RemoveNode(nodeID string) {
+	r.mu.Lock()
+	delete(r.nodes, nodeID)
+	r.mu.Unlock()
+	r.engine.DeregisterNode(nodeID)
+}
+
+// Rank returns the scores of all registered nodes, best first. Ties are
+// broken by node ID so the order is deterministic. Degraded nodes are
+// included (they score lower) so they remain a last-resort fallback.
+func (r *RoutedRetriever) Rank() []policy.NodeScore {
+	r.mu.RLock()
+	ids := make([]string, 0, len(r.nodes))
+	for id := range r.nodes {
+		ids = append(ids, id)
+	}
+	r.mu.RUnlock()
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return r.engine.Select(policy.SelectRequest{
+		PreferredGeo:  r.config.PreferredGeo,
+		Candidates:    ids,
+		AllowHalfOpen: true,
+	})
+}
+
+// Get retrieves the full content for cid from the best available node.
+func (r *RoutedRetriever) Get(ctx context.Context, cid string) (io.ReadCloser, error) {
+	return r.try(ctx, func(ctx context.Context, rt adapter.RetrieverAPI) (io.ReadCloser, error) {
+		return rt.Get(ctx, cid)
+	})
+}
+
+// GetRange retrieves [start, end) for cid from the best available node.
+func (r *RoutedRetriever) GetRange(ctx context.Context, cid string, start, end uint64) (io.ReadCloser, error) {
+	return r.try(ctx, func(ctx context.Context, rt adapter.RetrieverAPI) (io.ReadCloser, error) {
+		return rt.GetRange(ctx, cid, start, end)
+	})
+}
+
+// Stat queries every registered node concurrently. The result comes from
+// the best-ranked node that answered, with Nodes listing every node that
+// reported holding the object. Nodes that do not implement adapter.Stater
+// are skipped, and each node is bounded by Config.AttemptTimeout so one
+// hung node cannot stall the call.
+func (r *RoutedRetriever) Stat(ctx context.Context, cid string) (adapter.ObjectInfo, error) {
+	ranked := r.Rank()
+	if len(ranked) == 0 {
+		return adapter.ObjectInfo{}, ErrNoNodes
+	}
+
+	type statResult struct {
+		info adapter.ObjectInfo
+		err  error
+	}
+	results := make([]statResult, len(ranked))
+	var wg sync.WaitGroup
+	for i, ns := range ranked {
+		r.mu.RLock()
+		rt, ok := r.nodes[ns.NodeID]
+		r.mu.RUnlock()
+		if !ok {
+			results[i].err = adapter.ErrNotFound
+			continue
+		}
+		wg.Add(1)
+		go func(i int, rt adapter.RetrieverAPI) {
+			defer wg.Done()
+			sctx := ctx
+			if r.config.AttemptTimeout > 0 {
+				var cancel context.CancelFunc
+				sctx, cancel = context.WithTimeout(ctx, r.config.AttemptTimeout)
+				defer cancel()
+			}
+			info, err := adapter.StatOf(sctx, rt, cid)
+			results[i] = statResult{info: info, err: err}
+		}(i, rt)
+	}
+	wg.Wait()
+
+	var best *adapter.ObjectInfo
+	var nodes []string
+	errs := []error{ErrAllNodesFailed}
+	supported := false
+	for i, res := range results {
+		if !errors.Is(res.err, adapter.ErrStatUnsupported) {
+			supported = true
+		}
+		if res.err != nil {
+			errs = append(errs, fmt.Errorf("node %s: %w", ranked[i].NodeID, res.err))
+			continue
+		}
+		if best == nil {
+			info := res.info
+			best = &info
+		}
+		nodes = append(nodes, ranked[i].NodeID)
+	}
+	if best == nil {
+		if !supported {
+			return adapter.ObjectInfo{}, adapter.ErrStatUnsupported
+		}
+		return adapter.ObjectInfo{}, errors.Join(errs...)
+	}
+	best.Nodes = nodes
+	return *best, nil
+}
+
+type fetchFunc func(ctx context.Context, rt adapter.RetrieverAPI) (io.ReadCloser, error)
+
+func (r *RoutedRetriever) try(ctx context.Context, fetch fetchFunc) (io.ReadCloser, error) {
+	ranked := r.Rank()
+	if len(ranked) == 0 {
+		return nil, ErrNoNodes
+	}
+
+	errs := []error{ErrAllNodesFailed}
+	for _, ns := range ranked {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		r.mu.RLock()
+		rt, ok := r.nodes[ns.NodeID]
+		r.mu.RUnlock()
+		if !ok {
+			continue // removed since ranking
+		}
+
+		rc, err := r.attempt(ctx, ns.NodeID, rt, fetch)
+		if err == nil {
+			return rc, nil
+		}
+		r.recordFailure(ctx, ns.NodeID, err)
+		errs = append(errs, fmt.Errorf("node %s: %w", ns.NodeID, err))
+	}
+	return nil, errors.Join(errs...)
+}
+
+// attempt runs fetch against one node. The per-node context stays alive
+// until the returned body is closed, so the timeout only bounds the time to
+// obtain a response, not the transfer itself.
+func (r *RoutedRetriever) attempt(ctx context.Context, nodeID string, rt adapter.RetrieverAPI, fetch fetchFunc) (io.ReadCloser, error) {
+	actx, cancel := context.WithCancel(ctx)
+	var timer *time.Timer
+	if r.config.AttemptTimeout > 0 {
+		timer = time.AfterFunc(r.config.AttemptTimeout, cancel)
+	}
+
+	began := time.Now()
+	rc, err := fetch(actx, rt)
+	elapsed := time.Since(began)
+	timedOut := timer != nil && !timer.Stop()
+
+	if err != nil || timedOut {
+		cancel()
+		if rc != nil {
+			rc.Close()
+		}
+		if timedOut {
+			// A timeout is a real observation of a slow node.
+			r.engine.RecordLatency(nodeID, elapsed)
+			return nil, fmt.Errorf("timed out after %v: %w", r.config.AttemptTimeout, context.DeadlineExceeded)
+		}
+		return nil, err
+	}
+
+	r.engine.RecordLatency(nodeID, elapsed)
+	return r.meter(nodeID, rc, cancel), nil
+}
+
+// recordFailure feeds a node-attributable failure to the engine. Misses
+// (not found, unsatisfiable range) and cancellation by the caller are not
+// the node's fault and are not recorded.
+func (r *RoutedRetriever) recordFailure(ctx context.Context, nodeID string, err error) {
+	switch {
+	case ctx.Err() != nil,
+		errors.Is(err, adapter.ErrNotFound),
+		errors.Is(err, adapter.ErrRangeNotSatisfiable):
+		return
+	case errors.Is(err, context.DeadlineExceeded):
+		r.engine.RecordError(nodeID, policy.ErrorTimeout)
+	default:
+		r.engine.RecordError(nodeID, policy.ErrorUnavailable)
+	}
+}
+
+// meter wraps a node's response body so that closing it releases the
+// per-attempt context and reports the transfer to the engine.
+func (r *RoutedRetriever) meter(nodeID string, rc io.ReadCloser, cancel context.CancelFunc) io.ReadCloser {
+	return &meteredBody{ReadCloser: rc, cancel: cancel, engine: r.engine, nodeID: nodeID}
+}
+
+// meteredBody counts bytes and the time spent inside Read, so a slow
+// consumer does not count against the node's throughput. A read error
+// other than EOF or cancellation is recorded as a stall; an interrupted
+// body's bytes count toward throughput but not as a success.
+type meteredBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+	engine *policy.Engine
+	nodeID string
+
+	n    int64
+	busy time.Duration
+	err  error
+	once sync.Once
+}
+
+func (b *meteredBody) Read(p []byte) (int, error) {
+	began := time.Now()
+	n, err := b.ReadCloser.Read(p)
+	b.busy += time.Since(began)
+	b.n += int64(n)
+	if err != nil && err != io.EOF && b.err == nil {
+		b.err = err
+	}
+	return n, err
+}
+
+func (b *meteredBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() {
+		b.cancel()
+		if b.err != nil && !errors.Is(b.err, context.Canceled) {
+			b.engine.RecordError(b.nodeID, policy.ErrorStall)
+		}
+		switch {
+		case b.n == 0:
+		case b.err != nil:
+			b.engine.RecordPartialTransfer(b.nodeID, b.n, b.busy)
+		default:
+			b.engine.RecordTransfer(b.nodeID, b.n, b.busy)
+		}
+	})
+	return err
+}
+
+// Compile-time interface checks.
+var (
+	_ adapter.RetrieverAPI = (*RoutedRetriever)(nil)
+	_ adapter.Stater       = (*RoutedRetriever)(nil)
+)
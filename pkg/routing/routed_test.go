@@ -0,0 +1,297 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quriustus/filstream-curio-adapter/internal/mock"
+	"github.com/quriustus/filstream-curio-adapter/pkg/adapter"
+	"github.com/quriustus/filstream-curio-adapter/pkg/policy"
+)
+
+// stubRetriever serves fixed content, optionally after a delay or with an error.
+type stubRetriever struct {
+	data  string
+	delay time.Duration
+	err   error
+	calls int
+}
+
+func (s *stubRetriever) Get(ctx context.Context, cid string) (io.ReadCloser, error) {
+	return s.GetRange(ctx, cid, 0, uint64(len(s.data)))
+}
+
+func (s *stubRetriever) GetRange(ctx context.Context, cid string, start, end uint64) (io.ReadCloser, error) {
+	s.calls++
+	if s.delay > 0 {
+		select {
+		case <-time.After(s.delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	return io.NopCloser(strings.NewReader(s.data[start:end])), nil
+}
+
+func seedLatency(eng *policy.Engine, nodeID string, d time.Duration) {
+	for i := 0; i < 15; i++ {
+		eng.RecordLatency(nodeID, d)
+	}
+}
+
+func TestRoutedRetriever_PrefersBestScore(t *testing.T) {
+	eng := policy.NewEngine(policy.DefaultConfig())
+	seedLatency(eng, "fast", 10*time.Millisecond)
+	seedLatency(eng, "slow", 5*time.Second)
+
+	fast := &stubRetriever{data: "from-fast"}
+	slow := &stubRetriever{data: "from-slow"}
+	r := NewRoutedRetriever(eng, Config{})
+	r.AddNode("fast", fast)
+	r.AddNode("slow", slow)
+
+	rc, err := r.Get(context.Background(), "bafydeadbeef")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	data, _ := io.ReadAll(rc)
+	if string(data) != "from-fast" {
+		t.Fatalf("expected fast node, got %q", data)
+	}
+	if slow.calls != 0 {
+		t.Fatal("slow node should not have been tried")
+	}
+	if got := eng.Score("fast", "").SampleCount; got != 16 {
+		t.Fatalf("expected latency fed back (16 samples), got %d", got)
+	}
+}
+
+func TestRoutedRetriever_GeoPreference(t *testing.T) {
+	eng := policy.NewEngine(policy.DefaultConfig())
+	seedLatency(eng, "node-us", 20*time.Millisecond)
+	seedLatency(eng, "node-eu", 20*time.Millisecond)
+	eng.SetGeoLabel("node-us", "us-east")
+	eng.SetGeoLabel("node-eu", "eu-west")
+
+	r := NewRoutedRetriever(eng, Config{PreferredGeo: "eu-west"})
+	r.AddNode("node-us", &stubRetriever{data: "us"})
+	r.AddNode("node-eu", &stubRetriever{data: "eu"})
+
+	ranked := r.Rank()
+	if ranked[0].NodeID != "node-eu" {
+		t.Fatalf("expected node-eu first, got %s", ranked[0].NodeID)
+	}
+}
+
+func TestRoutedRetriever_FailoverOnError(t *testing.T) {
+	eng := policy.NewEngine(policy.DefaultConfig())
+	seedLatency(eng, "primary", 10*time.Millisecond)
+	seedLatency(eng, "backup", 50*time.Millisecond)
+
+	r := NewRoutedRetriever(eng, Config{})
+	r.AddNode("primary", &stubRetriever{err: errors.New("boom")})
+	r.AddNode("backup", mock.NewBackend())
+
+	rc, err := r.GetRange(context.Background(), "bafydeadbeef", 0, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	data, _ := io.ReadAll(rc)
+	if string(data) != "hello" {
+		t.Fatalf("unexpected data: %q", data)
+	}
+}
+
+func TestRoutedRetriever_FailoverOnTimeout(t *testing.T) {
+	eng := policy.NewEngine(policy.DefaultConfig())
+	seedLatency(eng, "stalled", 10*time.Millisecond)
+	seedLatency(eng, "backup", 50*time.Millisecond)
+
+	r := NewRoutedRetriever(eng, Config{AttemptTimeout: 20 * time.Millisecond})
+	r.AddNode("stalled", &stubRetriever{data: "late", delay: time.Second})
+	r.AddNode("backup", &stubRetriever{data: "ok"})
+
+	rc, err := r.Get(context.Background(), "bafydeadbeef")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	data, _ := io.ReadAll(rc)
+	if string(data) != "ok" {
+		t.Fatalf("expected backup data, got %q", data)
+	}
+	if got := eng.Score("stalled", "").SampleCount; got != 16 {
+		t.Fatalf("expected timeout recorded as latency sample, got %d samples", got)
+	}
+}
+
+func TestRoutedRetriever_AllFail(t *testing.T) {
+	eng := policy.NewEngine(policy.DefaultConfig())
+	r := NewRoutedRetriever(eng, Config{})
+
+	if _, err := r.Get(context.Background(), "x"); !errors.Is(err, ErrNoNodes) {
+		t.Fatalf("expected ErrNoNodes, got %v", err)
+	}
+
+	cause := errors.New("disk on fire")
+	r.AddNode("a", &stubRetriever{err: cause})
+	r.AddNode("b", &stubRetriever{err: cause})
+	_, err := r.Get(context.Background(), "x")
+	if !errors.Is(err, ErrAllNodesFailed) || !errors.Is(err, cause) {
+		t.Fatalf("expected joined ErrAllNodesFailed, got %v", err)
+	}
+}
+
+func TestRoutedRetriever_Stat(t *testing.T) {
+	eng := policy.NewEngine(policy.DefaultConfig())
+	r := NewRoutedRetriever(eng, Config{})
+
+	r.AddNode("stub", &stubRetriever{data: "no stat support"})
+	if _, err := r.Stat(context.Background(), "bafydeadbeef"); !errors.Is(err, adapter.ErrStatUnsupported) {
+		t.Fatalf("expected ErrStatUnsupported, got %v", err)
+	}
+
+	r.AddNode("a", mock.NewBackend())
+	r.AddNode("b", mock.NewBackend())
+	info, err := r.Stat(context.Background(), "bafydeadbeef")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size != 15 || len(info.Nodes) != 2 {
+		t.Fatalf("expected size 15 on nodes a and b, got %+v", info)
+	}
+}
+
+// hungStater never answers Stat until its context ends.
+type hungStater struct{ stubRetriever }
+
+func (h *hungStater) Stat(ctx context.Context, cid string) (adapter.ObjectInfo, error) {
+	<-ctx.Done()
+	return adapter.ObjectInfo{}, ctx.Err()
+}
+
+func TestRoutedRetriever_StatAttemptTimeout(t *testing.T) {
+	eng := policy.NewEngine(policy.DefaultConfig())
+	r := NewRoutedRetriever(eng, Config{AttemptTimeout: 20 * time.Millisecond})
+	r.AddNode("hung", &hungStater{})
+	r.AddNode("ok", mock.NewBackend())
+
+	began := time.Now()
+	info, err := r.Stat(context.Background(), "bafydeadbeef")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(began); elapsed > time.Second {
+		t.Fatalf("Stat took %v despite AttemptTimeout", elapsed)
+	}
+	if info.Size != 15 || len(info.Nodes) != 1 || info.Nodes[0] != "ok" {
+		t.Fatalf("expected size 15 on node ok only, got %+v", info)
+	}
+}
+
+// stallingBody returns some bytes and then a read error.
+type stallingBody struct{ sent bool }
+
+func (b *stallingBody) Read(p []byte) (int, error) {
+	if !b.sent {
+		b.sent = true
+		return copy(p, "partial"), nil
+	}
+	return 0, errors.New("connection reset")
+}
+
+func (b *stallingBody) Close() error { return nil }
+
+type stallingRetriever struct{ stubRetriever }
+
+func (s *stallingRetriever) GetRange(ctx context.Context, cid string, start, end uint64) (io.ReadCloser, error) {
+	return &stallingBody{}, nil
+}
+
+func TestRoutedRetriever_RecordsTransfersAndErrors(t *testing.T) {
+	eng := policy.NewEngine(policy.DefaultConfig())
+	seedLatency(eng, "down", 10*time.Millisecond)
+	seedLatency(eng, "up", 20*time.Millisecond)
+
+	r := NewRoutedRetriever(eng, Config{})
+	r.AddNode("down", &stubRetriever{err: errors.New("503")})
+	r.AddNode("up", &stubRetriever{data: "segment-bytes"})
+
+	rc, err := r.Get(context.Background(), "bafydeadbeef")
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.ReadAll(rc)
+	rc.Close()
+	rc.Close() // double close records once
+
+	if s := eng.Score("down", ""); s.ErrorCount != 1 || s.ErrorRate != 1 {
+		t.Fatalf("expected one recorded error, got %+v", s)
+	}
+	if s := eng.Score("up", ""); s.TransferCount != 1 || s.ErrorCount != 0 {
+		t.Fatalf("expected one recorded transfer, got %+v", s)
+	}
+
+	// Misses are not the node's fault.
+	r.RemoveNode("down")
+	r.AddNode("miss", &stubRetriever{err: adapter.ErrNotFound})
+	seedLatency(eng, "miss", time.Millisecond)
+	if rc, err := r.Get(context.Background(), "bafydeadbeef"); err == nil {
+		rc.Close()
+	}
+	if s := eng.Score("miss", ""); s.ErrorCount != 0 {
+		t.Fatalf("expected not-found not recorded, got %+v", s)
+	}
+}
+
+func TestRoutedRetriever_RecordsStall(t *testing.T) {
+	eng := policy.NewEngine(policy.DefaultConfig())
+	r := NewRoutedRetriever(eng, Config{})
+	r.AddNode("flaky", &stallingRetriever{})
+
+	rc, err := r.GetRange(context.Background(), "bafydeadbeef", 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadAll(rc); err == nil {
+		t.Fatal("expected read error")
+	}
+	rc.Close()
+
+	s := eng.Explain("flaky", "").Inputs
+	if s.ErrorsByKind[policy.ErrorStall] != 1 || s.TransferCount != 1 || s.ErrorRate != 1 {
+		t.Fatalf("expected stall and partial transfer recorded, got %+v", s)
+	}
+}
+
+func TestRoutedRetriever_RegistersNodes(t *testing.T) {
+	cfg := policy.DefaultConfig()
+	cfg.RequireRegistration = true
+	eng := policy.NewEngine(cfg)
+	r := NewRoutedRetriever(eng, Config{})
+	r.AddNode("a", &stubRetriever{data: "x"})
+
+	eng.RecordLatency("a", time.Millisecond)
+	eng.RecordLatency("typo", time.Millisecond)
+	if nodes := eng.Nodes(); len(nodes) != 1 || !nodes[0].Registered || nodes[0].SampleCount != 1 {
+		t.Fatalf("expected only registered node a, got %+v", nodes)
+	}
+
+	r.RemoveNode("a")
+	eng.RecordLatency("a", time.Millisecond)
+	if nodes := eng.Nodes(); len(nodes) != 0 {
+		t.Fatalf("expected a deregistered, got %+v", nodes)
+	}
+	if got := eng.RejectedSamples(); got != 2 {
+		t.Fatalf("expected 2 rejected samples, got %d", got)
+	}
+}
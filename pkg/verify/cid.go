@@ -0,0 +1,204 @@
+// Package verify checks that bytes retrieved through adapter.RetrieverAPI
+// actually hash to the CID that was requested.
+package verify
+
+import (
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+	"strings"
+)
+
+// Multicodec content types understood by the verifier.
+const (
+	CodecRaw   uint64 = 0x55
+	CodecDagPB uint64 = 0x70
+)
+
+// Multihash function codes understood by the verifier.
+const (
+	HashSHA2_256   uint64 = 0x12
+	HashBlake2b256 uint64 = 0xb220
+
+	// blake2b-8 through blake2b-512 occupy 0xb201-0xb240; the low byte is
+	// the digest length in bytes.
+	hashBlake2bMin uint64 = 0xb201
+	hashBlake2bMax uint64 = 0xb240
+)
+
+// Sentinel errors for CID parsing.
+var (
+	ErrInvalidCID     = errors.New("verify: invalid cid")
+	ErrUnsupportedCID = errors.New("verify: unsupported cid codec or hash")
+)
+
+// CID is a parsed content identifier.
+type CID struct {
+	Version  uint64
+	Codec    uint64
+	HashCode uint64
+	Digest   []byte
+}
+
+// NewHash returns a fresh hasher for the CID's multihash function.
+func (c CID) NewHash() (hash.Hash, error) {
+	switch {
+	case c.HashCode == HashSHA2_256:
+		return sha256.New(), nil
+	case c.HashCode >= hashBlake2bMin && c.HashCode <= hashBlake2bMax:
+		return newBlake2b(int(c.HashCode - hashBlake2bMin + 1)), nil
+	}
+	return nil, fmt.Errorf("%w: multihash 0x%x", ErrUnsupportedCID, c.HashCode)
+}
+
+// String encodes the CID: CIDv0 as base58btc, CIDv1 as multibase base32.
+func (c CID) String() string {
+	mh := appendMultihash(nil, c.HashCode, c.Digest)
+	if c.Version == 0 {
+		return base58Encode(mh)
+	}
+	buf := binary.AppendUvarint(nil, 1)
+	buf = binary.AppendUvarint(buf, c.Codec)
+	buf = append(buf, mh...)
+	return "b" + base32Lower.EncodeToString(buf)
+}
+
+// ParseCID decodes a CIDv0 ("Qm…") or a base32/base58btc CIDv1 string and
+// checks that its codec and multihash are ones the verifier can handle.
+// dag-pb CIDs parse so callers can identify them, but only raw-codec
+// content can be verified by NewReader.
+func ParseCID(s string) (CID, error) {
+	if len(s) == 46 && strings.HasPrefix(s, "Qm") {
+		raw, err := base58Decode(s)
+		if err != nil {
+			return CID{}, err
+		}
+		code, digest, err := parseMultihash(raw)
+		if err != nil {
+			return CID{}, err
+		}
+		if code != HashSHA2_256 {
+			return CID{}, fmt.Errorf("%w: cidv0 must be sha2-256", ErrInvalidCID)
+		}
+		return CID{Version: 0, Codec: CodecDagPB, HashCode: code, Digest: digest}, nil
+	}
+
+	if len(s) < 2 {
+		return CID{}, fmt.Errorf("%w: %q", ErrInvalidCID, s)
+	}
+	var raw []byte
+	var err error
+	switch s[0] {
+	case 'b':
+		raw, err = base32Lower.DecodeString(s[1:])
+		if err != nil {
+			return CID{}, fmt.Errorf("%w: %v", ErrInvalidCID, err)
+		}
+	case 'z':
+		raw, err = base58Decode(s[1:])
+		if err != nil {
+			return CID{}, err
+		}
+	default:
+		return CID{}, fmt.Errorf("%w: unsupported multibase %q", ErrInvalidCID, s[0])
+	}
+
+	version, n := binary.Uvarint(raw)
+	if n <= 0 || version != 1 {
+		return CID{}, fmt.Errorf("%w: unsupported version", ErrInvalidCID)
+	}
+	raw = raw[n:]
+	codec, n := binary.Uvarint(raw)
+	if n <= 0 {
+		return CID{}, fmt.Errorf("%w: bad codec varint", ErrInvalidCID)
+	}
+	if codec != CodecRaw && codec != CodecDagPB {
+		return CID{}, fmt.Errorf("%w: codec 0x%x", ErrUnsupportedCID, codec)
+	}
+	code, digest, err := parseMultihash(raw[n:])
+	if err != nil {
+		return CID{}, err
+	}
+
+	c := CID{Version: 1, Codec: codec, HashCode: code, Digest: digest}
+	if _, err := c.NewHash(); err != nil {
+		return CID{}, err
+	}
+	return c, nil
+}
+
+// Sum computes the CIDv1 of data for the given codec and multihash function.
+func Sum(codec, hashCode uint64, data []byte) (CID, error) {
+	c := CID{Version: 1, Codec: codec, HashCode: hashCode}
+	h, err := c.NewHash()
+	if err != nil {
+		return CID{}, err
+	}
+	h.Write(data)
+	c.Digest = h.Sum(nil)
+	return c, nil
+}
+
+func parseMultihash(b []byte) (uint64, []byte, error) {
+	code, n := binary.Uvarint(b)
+	if n <= 0 {
+		return 0, nil, fmt.Errorf("%w: bad multihash code", ErrInvalidCID)
+	}
+	b = b[n:]
+	length, n := binary.Uvarint(b)
+	if n <= 0 || uint64(len(b)-n) != length {
+		return 0, nil, fmt.Errorf("%w: bad multihash length", ErrInvalidCID)
+	}
+	return code, b[n:], nil
+}
+
+func appendMultihash(buf []byte, code uint64, digest []byte) []byte {
+	buf = binary.AppendUvarint(buf, code)
+	buf = binary.AppendUvarint(buf, uint64(len(digest)))
+	return append(buf, digest...)
+}
+
+var base32Lower = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+func base58Decode(s string) ([]byte, error) {
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for i := 0; i < len(s); i++ {
+		idx := strings.IndexByte(base58Alphabet, s[i])
+		if idx < 0 {
+			return nil, fmt.Errorf("%w: invalid base58 character %q", ErrInvalidCID, s[i])
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(idx)))
+	}
+	out := n.Bytes()
+	// Leading '1's encode leading zero bytes.
+	for i := 0; i < len(s) && s[i] == '1'; i++ {
+		out = append([]byte{0}, out...)
+	}
+	return out, nil
+}
+
+func base58Encode(b []byte) string {
+	n := new(big.Int).SetBytes(b)
+	radix := big.NewInt(58)
+	mod := new(big.Int)
+	var out []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, radix, mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	for i := 0; i < len(b) && b[i] == 0; i++ {
+		out = append(out, '1')
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
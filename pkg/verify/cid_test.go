@@ -0,0 +1,131 @@
+package verify
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+func TestBlake2b512Vectors(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"", "786a02f742015903c6c6fd852552d272912f4740e15847618a86e217f71f5419d25e1031afee585313896444934eb04b903a685b1448b755d56f701afe9be2ce"},
+		{"abc", "ba80a53f981c4d0d6a2797b69f12f6e94c212f14685ac4b74b12bb6fdbffa2d17d87c5392aab792dc252d5de4533cc9518d38aa8dbf1925ab92386edd4009923"},
+	}
+	for _, tt := range tests {
+		h := newBlake2b(64)
+		h.Write([]byte(tt.in))
+		if got := hex.EncodeToString(h.Sum(nil)); got != tt.want {
+			t.Errorf("blake2b-512(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+// BLAKE2b-256 is what blake2b-256 CIDs use; vectors from the reference
+// implementation (b2sum -l 256).
+func TestBlake2b256Vectors(t *testing.T) {
+	long := make([]byte, 1000)
+	for i := range long {
+		long[i] = byte(i)
+	}
+	tests := []struct {
+		name string
+		in   []byte
+		want string
+	}{
+		{"empty", nil, "0e5751c026e543b2e8ab2eb06099daa1d1e5df47778f7787faab45cdf12fe3a8"},
+		{"abc", []byte("abc"), "bddd813c634239723171ef3fee98579b94964e3bb1cb3e427262c8c068d52319"},
+		{"quick brown fox", []byte("The quick brown fox jumps over the lazy dog"), "01718cec35cd3d796dd00020e0bfecb473ad23457d063b75eff29c0ffa2e58a9"},
+		{"multi-block", long, "c636324d47d89f2b2434dc2c994100663fbbaea880ff020fc5de89dd0f77a1ec"},
+	}
+	for _, tt := range tests {
+		h := newBlake2b(32)
+		h.Write(tt.in)
+		if got := hex.EncodeToString(h.Sum(nil)); got != tt.want {
+			t.Errorf("blake2b-256(%s) = %s, want %s", tt.name, got, tt.want)
+		}
+
+		c, err := Sum(CodecRaw, HashBlake2b256, tt.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(c.Digest); got != tt.want {
+			t.Errorf("Sum(blake2b-256, %s) digest = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBlake2bMultiBlock(t *testing.T) {
+	data := make([]byte, 1000)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	oneShot := newBlake2b(32)
+	oneShot.Write(data)
+
+	chunked := newBlake2b(32)
+	for i := 0; i < len(data); i += 7 {
+		end := i + 7
+		if end > len(data) {
+			end = len(data)
+		}
+		chunked.Write(data[i:end])
+	}
+
+	if hex.EncodeToString(oneShot.Sum(nil)) != hex.EncodeToString(chunked.Sum(nil)) {
+		t.Fatal("chunked writes produced a different digest")
+	}
+}
+
+func TestParseCID_RoundTrip(t *testing.T) {
+	for _, codec := range []uint64{CodecRaw, CodecDagPB} {
+		for _, hc := range []uint64{HashSHA2_256, HashBlake2b256} {
+			c, err := Sum(codec, hc, []byte("hello filstream"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			parsed, err := ParseCID(c.String())
+			if err != nil {
+				t.Fatalf("ParseCID(%s): %v", c, err)
+			}
+			if parsed.Codec != codec || parsed.HashCode != hc || hex.EncodeToString(parsed.Digest) != hex.EncodeToString(c.Digest) {
+				t.Fatalf("round trip mismatch: %+v vs %+v", parsed, c)
+			}
+		}
+	}
+}
+
+func TestParseCID_KnownStrings(t *testing.T) {
+	// Empty-file CIDs as produced by kubo.
+	tests := []struct {
+		cid   string
+		codec uint64
+	}{
+		{"bafkreihdwdcefgh4dqkjv67uzcmw7ojee6xedzdetojuzjevtenxquvyku", CodecRaw},
+		{"QmbFMke1KXqnYyBBWxB74N4c5SBnJMVAiMNRcGu6x1AwQH", CodecDagPB},
+	}
+	for _, tt := range tests {
+		c, err := ParseCID(tt.cid)
+		if err != nil {
+			t.Fatalf("ParseCID(%s): %v", tt.cid, err)
+		}
+		if c.Codec != tt.codec || c.HashCode != HashSHA2_256 {
+			t.Fatalf("unexpected parse for %s: %+v", tt.cid, c)
+		}
+		if c.String() != tt.cid {
+			t.Fatalf("String() = %s, want %s", c.String(), tt.cid)
+		}
+	}
+}
+
+func TestParseCID_Invalid(t *testing.T) {
+	if _, err := ParseCID("bafydeadbeef"); !errors.Is(err, ErrInvalidCID) {
+		t.Fatalf("expected ErrInvalidCID, got %v", err)
+	}
+	if _, err := ParseCID("x123"); !errors.Is(err, ErrInvalidCID) {
+		t.Fatalf("expected ErrInvalidCID, got %v", err)
+	}
+}
@@ -0,0 +1,175 @@
+package verify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"sync"
+
+	"github.com/quriustus/filstream-curio-adapter/pkg/adapter"
+)
+
+// ErrIntegrity is returned when retrieved bytes do not hash to the requested
+// CID. It is always wrapped in an *IntegrityError.
+var ErrIntegrity = errors.New("verify: content does not match cid")
+
+// IntegrityError reports a digest mismatch for a specific CID.
+type IntegrityError struct {
+	CID      string
+	Expected []byte
+	Actual   []byte
+}
+
+func (e *IntegrityError) Error() string {
+	return fmt.Sprintf("verify: content for %s hashed to %x, expected %x", e.CID, e.Actual, e.Expected)
+}
+
+func (e *IntegrityError) Unwrap() error { return ErrIntegrity }
+
+// IntegrityReporter receives verified integrity failures. *policy.Engine
+// implements it so nodes serving corrupt data are penalized.
+type IntegrityReporter interface {
+	RecordIntegrityFailure(nodeID string)
+}
+
+// Reader hashes an underlying stream as it is read and, once the stream is
+// exhausted, compares the digest to the CID. On mismatch the final Read
+// returns an *IntegrityError instead of io.EOF, and Close returns it too.
+type Reader struct {
+	rc     io.ReadCloser
+	cid    string
+	want   []byte
+	h      hash.Hash
+	onFail func(error)
+
+	once sync.Once
+	done bool
+	err  error
+}
+
+// NewReader wraps rc so its content is verified against cid. onFail, if not
+// nil, is called once when a mismatch is detected. Only raw-codec CIDs can
+// be verified this way; a dag-pb digest covers the root block, not the file
+// bytes, so other codecs return ErrUnsupportedCID.
+func NewReader(rc io.ReadCloser, cid string, onFail func(error)) (*Reader, error) {
+	c, err := ParseCID(cid)
+	if err != nil {
+		return nil, err
+	}
+	if c.Codec != CodecRaw {
+		return nil, fmt.Errorf("%w: codec 0x%x cannot be verified against the stream", ErrUnsupportedCID, c.Codec)
+	}
+	h, err := c.NewHash()
+	if err != nil {
+		return nil, err
+	}
+	return &Reader{rc: rc, cid: cid, want: c.Digest, h: h, onFail: onFail}, nil
+}
+
+func (r *Reader) Read(p []byte) (int, error) {
+	if r.done {
+		if r.err != nil {
+			return 0, r.err
+		}
+		return 0, io.EOF
+	}
+	n, err := r.rc.Read(p)
+	r.h.Write(p[:n])
+	if errors.Is(err, io.EOF) {
+		r.finish()
+		if r.err != nil {
+			return n, r.err
+		}
+	}
+	return n, err
+}
+
+// Close closes the underlying stream. If the stream was read to EOF and the
+// digest did not match, the integrity error is returned.
+func (r *Reader) Close() error {
+	cerr := r.rc.Close()
+	if r.err != nil {
+		return r.err
+	}
+	return cerr
+}
+
+// Verified reports whether the stream was fully read and matched the CID.
+func (r *Reader) Verified() bool {
+	return r.done && r.err == nil
+}
+
+func (r *Reader) finish() {
+	r.once.Do(func() {
+		r.done = true
+		got := r.h.Sum(nil)
+		if bytes.Equal(got, r.want) {
+			return
+		}
+		r.err = &IntegrityError{CID: r.cid, Expected: r.want, Actual: got}
+		if r.onFail != nil {
+			r.onFail(r.err)
+		}
+	})
+}
+
+// Retriever wraps a single node's RetrieverAPI and verifies full-object
+// reads of raw-codec CIDs against their CID. Range reads, and full reads of
+// dag-pb CIDs, cannot be checked against the root digest and are passed
+// through unverified.
+type Retriever struct {
+	next     adapter.RetrieverAPI
+	nodeID   string
+	reporter IntegrityReporter
+}
+
+// NewRetriever wraps next, which serves content from nodeID. reporter may be
+// nil.
+func NewRetriever(next adapter.RetrieverAPI, nodeID string, reporter IntegrityReporter) *Retriever {
+	return &Retriever{next: next, nodeID: nodeID, reporter: reporter}
+}
+
+// Get retrieves the full content for cid, verifying it as it is read if cid
+// is raw-codec.
+func (v *Retriever) Get(ctx context.Context, cid string) (io.ReadCloser, error) {
+	c, err := ParseCID(cid)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := v.next.Get(ctx, cid)
+	if err != nil {
+		return nil, err
+	}
+	if c.Codec != CodecRaw {
+		return rc, nil
+	}
+	r, err := NewReader(rc, cid, func(error) {
+		if v.reporter != nil {
+			v.reporter.RecordIntegrityFailure(v.nodeID)
+		}
+	})
+	if err != nil {
+		rc.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetRange passes through to the wrapped retriever without verification.
+func (v *Retriever) GetRange(ctx context.Context, cid string, start, end uint64) (io.ReadCloser, error) {
+	return v.next.GetRange(ctx, cid, start, end)
+}
+
+// Stat passes through to the wrapped retriever.
+func (v *Retriever) Stat(ctx context.Context, cid string) (adapter.ObjectInfo, error) {
+	return adapter.StatOf(ctx, v.next, cid)
+}
+
+// Compile-time interface checks.
+var (
+	_ adapter.RetrieverAPI = (*Retriever)(nil)
+	_ adapter.Stater       = (*Retriever)(nil)
+)
@@ -0,0 +1,128 @@
+package verify
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/quriustus/filstream-curio-adapter/internal/mock"
+	"github.com/quriustus/filstream-curio-adapter/pkg/policy"
+)
+
+var _ IntegrityReporter = (*policy.Engine)(nil)
+
+type countingReporter struct {
+	failures map[string]int
+}
+
+func (c *countingReporter) RecordIntegrityFailure(nodeID string) {
+	c.failures[nodeID]++
+}
+
+func TestRetriever_VerifiedGet(t *testing.T) {
+	data := []byte("hello filstream")
+	c, _ := Sum(CodecRaw, HashSHA2_256, data)
+
+	b := mock.NewBackend()
+	b.AddObject(c.String(), data)
+	rep := &countingReporter{failures: map[string]int{}}
+	r := NewRetriever(b, "node-1", rep)
+
+	rc, err := r.Get(context.Background(), c.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	if err := rc.Close(); err != nil {
+		t.Fatalf("unexpected close error: %v", err)
+	}
+	if string(got) != string(data) {
+		t.Fatalf("unexpected data: %q", got)
+	}
+	if !rc.(*Reader).Verified() {
+		t.Fatal("expected reader to report verified")
+	}
+	if rep.failures["node-1"] != 0 {
+		t.Fatal("expected no integrity failures")
+	}
+}
+
+func TestRetriever_CorruptGet(t *testing.T) {
+	c, _ := Sum(CodecRaw, HashBlake2b256, []byte("hello filstream"))
+
+	b := mock.NewBackend()
+	b.AddObject(c.String(), []byte("hello filstreaM"))
+	eng := policy.NewEngine(policy.DefaultConfig())
+	r := NewRetriever(b, "node-bad", eng)
+
+	rc, err := r.Get(context.Background(), c.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = io.ReadAll(rc)
+	if !errors.Is(err, ErrIntegrity) {
+		t.Fatalf("expected ErrIntegrity from read, got %v", err)
+	}
+	var ie *IntegrityError
+	if !errors.As(rc.Close(), &ie) || ie.CID != c.String() {
+		t.Fatalf("expected *IntegrityError from close")
+	}
+
+	if got := eng.Score("node-bad", "").IntegrityFailures; got != 1 {
+		t.Fatalf("expected 1 integrity failure recorded, got %d", got)
+	}
+}
+
+func TestRetriever_RejectsUnparseableCID(t *testing.T) {
+	r := NewRetriever(mock.NewBackend(), "node-1", nil)
+	if _, err := r.Get(context.Background(), "bafydeadbeef"); !errors.Is(err, ErrInvalidCID) {
+		t.Fatalf("expected ErrInvalidCID, got %v", err)
+	}
+}
+
+func TestRetriever_DagPBPassthrough(t *testing.T) {
+	// A dag-pb digest covers the root block, so the file bytes never match it.
+	data := []byte("hello filstream")
+	c, _ := Sum(CodecDagPB, HashSHA2_256, []byte("root block"))
+
+	b := mock.NewBackend()
+	b.AddObject(c.String(), data)
+	rep := &countingReporter{failures: map[string]int{}}
+	r := NewRetriever(b, "node-1", rep)
+
+	rc, err := r.Get(context.Background(), c.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(rc)
+	if err != nil || string(got) != string(data) {
+		t.Fatalf("expected dag-pb content passed through, got %q, %v", got, err)
+	}
+	if err := rc.Close(); err != nil {
+		t.Fatalf("unexpected close error: %v", err)
+	}
+	if rep.failures["node-1"] != 0 {
+		t.Fatal("expected no integrity failure for unverifiable content")
+	}
+
+	if _, err := NewReader(io.NopCloser(nil), c.String(), nil); !errors.Is(err, ErrUnsupportedCID) {
+		t.Fatalf("expected NewReader to reject dag-pb, got %v", err)
+	}
+}
+
+func TestRetriever_RangePassthrough(t *testing.T) {
+	r := NewRetriever(mock.NewBackend(), "node-1", nil)
+	rc, err := r.GetRange(context.Background(), "bafydeadbeef", 0, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	got, _ := io.ReadAll(rc)
+	if string(got) != "hello" {
+		t.Fatalf("unexpected data: %q", got)
+	}
+}
@@ -2,11 +2,13 @@ package test
 
 import (
 	"context"
+	"errors"
 	"io"
 	"testing"
 	"time"
 
 	"github.com/quriustus/filstream-curio-adapter/internal/mock"
+	"github.com/quriustus/filstream-curio-adapter/pkg/adapter"
 	"github.com/quriustus/filstream-curio-adapter/pkg/policy"
 )
 
@@ -161,3 +163,54 @@ func TestScoringProofPenalty(t *testing.T) {
 		t.Fatalf("expected penalty: good=%f bad=%f", scoreGood.Score, scoreBad.Score)
 	}
 }
+
+func TestScoringIntegrityFailurePenalty(t *testing.T) {
+	eng := policy.NewEngine(policy.DefaultConfig())
+
+	for i := 0; i < 15; i++ {
+		eng.RecordLatency("node-1", 20*time.Millisecond)
+	}
+	scoreGood := eng.Score("node-1", "")
+
+	// Corrupt data counts against the same grace budget as missed proofs.
+	for i := 0; i < 3; i++ {
+		eng.RecordIntegrityFailure("node-1")
+	}
+
+	scoreBad := eng.Score("node-1", "")
+	if scoreBad.IntegrityFailures != 3 {
+		t.Fatalf("expected 3 integrity failures, got %d", scoreBad.IntegrityFailures)
+	}
+	if !scoreBad.HalfOpen || scoreBad.Score >= scoreGood.Score {
+		t.Fatalf("expected half-open penalty: good=%f bad=%f", scoreGood.Score, scoreBad.Score)
+	}
+}
+
+func TestStatAndClampRange(t *testing.T) {
+	b := mock.NewBackend()
+	ctx := context.Background()
+
+	info, err := b.Stat(ctx, "bafy5678chunk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size != 256*1024 || len(info.Nodes) != 2 {
+		t.Fatalf("unexpected stat: %+v", info)
+	}
+
+	// Open-ended range from 1024 to the end of the object.
+	start, end, err := adapter.ClampRange(ctx, b, "bafy5678chunk", 1024, adapter.OpenEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if start != 1024 || end != 256*1024 {
+		t.Fatalf("expected [1024, %d), got [%d, %d)", 256*1024, start, end)
+	}
+
+	if _, _, err := adapter.ClampRange(ctx, b, "bafydeadbeef", 15, adapter.OpenEnd); !errors.Is(err, adapter.ErrRangeNotSatisfiable) {
+		t.Fatalf("expected ErrRangeNotSatisfiable, got %v", err)
+	}
+	if _, err := b.Stat(ctx, "bafymissing"); !errors.Is(err, adapter.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}